@@ -2,17 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/fogleman/delaunay"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
 	"gitlab.com/patopest/go-sacn"
-	"gitlab.com/patopest/go-sacn/packet"
 )
 
 type App struct {
@@ -22,15 +27,49 @@ type App struct {
 	fixtures          map[string]Fixture
 	universeDMXData   map[uint16]DMXData
 	calibrationPoints map[string]CalibrationPoint
-	sender            *sacn.Sender
-	activeUniverses   map[uint16]chan<- packet.SACNPacket
-
-	sacnStopLoop        chan bool
-	sacnUpdatedConfig   chan bool
-	sacnConfig          *SACNConfig
-	sacnWorkerWG        sync.WaitGroup
-	linearInterpolators map[string]*Linear2DPanTiltInterpolator
-	lastPanTilt         map[string]PanTilt
+	outputs           map[string]DMXOutput // by protocol
+	activeUniverses   map[uint16]string    // protocol by universe
+	stoppingOutputs   bool                 // the worker is sending the home position, see sendHome
+	sacnCID           [16]byte
+	sacnSequences     map[uint16]uint8 // last sequence numbers of the closed sACN output, continued by the next
+	receiver          *sacn.Receiver
+	receiverUniverses map[uint16]bool
+	receiverError     string          // last failure to create the receiver, logged once
+	receiverRetryAt   time.Time       // when to create the receiver or join universes again after a failure
+	failedJoins       map[uint16]bool // universes which failed to join, logged once
+	sacnInputs        map[uint16]*sacnInput
+	receivedSequences map[uint16]map[[16]byte]uint8            // sequence last accepted by universe, then CID
+	monitor           map[uint16]map[[16]byte]*monitoredSource // sources by monitored universe, then CID
+	discoveredSources map[[16]byte]*discoveredSource           // by CID
+	followedOutputs   map[uint16]*followedOutput
+	sentPriorities    map[uint16]*sentPriorities
+	transmitted       map[uint16]*transmittedUniverse
+	sendStatistics    map[uint16]*SendStatistics
+	sourceConflicts   map[uint16]map[[16]byte]*sourceConflict // by output universe, then CID
+
+	sacnStopLoop         chan bool
+	sacnUpdatedConfig    chan bool
+	sacnDataChanged      chan bool
+	sacnConfig           *SACNConfig
+	sacnWorkerWG         sync.WaitGroup
+	linearInterpolators  map[string]map[string]PanTiltInterpolator            // by layer, then fixture id
+	channelInterpolators map[string]map[string]map[string]PanTiltInterpolator // by layer, fixture id, then channel id
+	interpolatorsBuilt   int                                                  // generation of the calibration the interpolators were built for
+	calibrationChanges   int
+	lastPanTilt          map[string]PanTilt
+	motionFilters        map[string]*motionFilterState
+	fixtureProfiles      map[string]FixtureProfile
+	patchProblems        []string
+	activeLayer          string
+	layerRegions         []LayerRegion
+	remoteConfig         RemoteConfig
+	osc                  *oscServer
+	http                 *httpServer
+	targetPositions      map[string]Point // the positions on the video the fixtures of each target last followed
+	fixtureControls      map[string]FixtureControl
+	presets              []Preset
+	cues                 []Cue
+	cue                  *runningCue // the cue holding the fixtures, nil while they follow
 }
 
 func NewApp() *App {
@@ -41,7 +80,19 @@ func (a *App) startup(ctx context.Context) {
 	LogInfo("App startup beginning")
 	a.ctx = ctx
 
-	a.activeUniverses = make(map[uint16]chan<- packet.SACNPacket)
+	a.outputs = make(map[string]DMXOutput)
+	a.activeUniverses = make(map[uint16]string)
+	a.receiverUniverses = make(map[uint16]bool)
+	a.failedJoins = make(map[uint16]bool)
+	a.sacnInputs = make(map[uint16]*sacnInput)
+	a.receivedSequences = make(map[uint16]map[[16]byte]uint8)
+	a.monitor = make(map[uint16]map[[16]byte]*monitoredSource)
+	a.discoveredSources = make(map[[16]byte]*discoveredSource)
+	a.followedOutputs = make(map[uint16]*followedOutput)
+	a.sentPriorities = make(map[uint16]*sentPriorities)
+	a.transmitted = make(map[uint16]*transmittedUniverse)
+	a.sendStatistics = make(map[uint16]*SendStatistics)
+	a.sourceConflicts = make(map[uint16]map[[16]byte]*sourceConflict)
 	a.fixtures = make(map[string]Fixture)
 	a.calibrationPoints = make(map[string]CalibrationPoint)
 	a.universeDMXData = make(map[uint16]DMXData)
@@ -49,13 +100,22 @@ func (a *App) startup(ctx context.Context) {
 		Fps:          25,
 		Multicast:    true,
 		Destinations: []string{},
+		Universes:    []SACNUniverseConfig{},
 	}
 	a.sacnWorkerWG = sync.WaitGroup{}
 	a.sacnStopLoop = make(chan bool)
 	a.sacnUpdatedConfig = make(chan bool)
+	a.sacnDataChanged = make(chan bool, 1)
 
-	a.linearInterpolators = make(map[string]*Linear2DPanTiltInterpolator)
+	a.linearInterpolators = make(map[string]map[string]PanTiltInterpolator)
 	a.lastPanTilt = make(map[string]PanTilt)
+	a.targetPositions = make(map[string]Point)
+	a.fixtureControls = make(map[string]FixtureControl)
+	a.motionFilters = make(map[string]*motionFilterState)
+	a.fixtureProfiles = loadFixtureProfiles()
+	a.patchProblems = []string{}
+	a.activeLayer = defaultLayer
+	a.layerRegions = []LayerRegion{}
 
 	a.findPossibleIPAddresses()
 
@@ -63,11 +123,14 @@ func (a *App) startup(ctx context.Context) {
 	LogInfo("Starting sACN worker goroutine")
 	go a.sacnWorkerLoop()
 
+	a.startRemoteControl()
+
 	LogInfo("App startup complete")
 }
 
 func (a *App) shutdown(ctx context.Context) {
 	LogInfo("App shutdown beginning")
+	a.closeRemoteControl()
 	if a.sacnStopLoop != nil {
 		LogInfo("Sending stop signal to sACN worker")
 		close(a.sacnStopLoop)
@@ -114,91 +177,307 @@ func (a *App) ConfirmDialog(title string, message string) string {
 func (a *App) SetCalibrationPoints(calibrationPoints map[string]CalibrationPoint) {
 	LogInfo("SetCalibrationPoints: %d point(s)", len(calibrationPoints))
 	a.mu.Lock()
-	defer a.mu.Unlock()
 	a.calibrationPoints = calibrationPoints
+	a.mu.Unlock()
 	a.calculateLinearInterpolator()
 }
 
 func (a *App) SetFixtures(fixtures map[string]Fixture) {
 	LogInfo("SetFixtures: %d fixture(s)", len(fixtures))
+	a.setFixtures(fixtures)
+	a.calculateLinearInterpolator()
+}
+
+func (a *App) setFixtures(fixtures map[string]Fixture) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.fixtures = fixtures
+
+	a.fixtures = make(map[string]Fixture, len(fixtures))
+	problems := make([]string, 0)
+	for id, fixture := range fixtures {
+		resolved, err := resolveFixture(fixture, a.fixtureProfiles)
+		if err != nil {
+			LogError("Failed to resolve profile of fixture %s (%s): %s", fixture.Id, fixture.Name, err.Error())
+			problems = append(problems, fixture.Name+": "+err.Error())
+		}
+		a.fixtures[id] = resolved
+	}
+	a.patchProblems = append(problems, patchProblems(a.fixtures, a.fixtureProfiles)...)
+	for _, problem := range a.patchProblems {
+		LogError("Patch problem: %s", problem)
+	}
+
 	a.universeDMXData = make(map[uint16]DMXData)
-	a.calculateLinearInterpolator()
+	a.writeProfileDefaults()
+	a.applyParkedFixtures()
+	a.dmxChanged()
 }
 
+// calculateLinearInterpolator builds, for every calibration layer, the interpolator selected by each
+// fixture over the points of the layer it is calibrated for. Solving fixture poses can take a while,
+// so the interpolators are built from a copy of the calibration without holding the lock.
 func (a *App) calculateLinearInterpolator() {
-	a.linearInterpolators = make(map[string]*Linear2DPanTiltInterpolator)
+	a.mu.Lock()
+	a.calibrationChanges++
+	generation := a.calibrationChanges
+	fixtures := maps.Clone(a.fixtures)
+	layers := a.calibrationPointsByLayer()
+	a.mu.Unlock()
+
+	linearInterpolators := make(map[string]map[string]PanTiltInterpolator)
+	channelInterpolators := make(map[string]map[string]map[string]PanTiltInterpolator)
+	for layer, calibrationPoints := range layers {
+		linearInterpolators[layer], channelInterpolators[layer] = calculateLayerInterpolators(fixtures, layer, calibrationPoints)
+	}
 
-	points := make([]Point, len(a.calibrationPoints))
-	pointsIndexMap := make(map[string]int)
-	index := 0
-	for _, calibrationPoint := range a.calibrationPoints {
-		points[index] = Point{X: calibrationPoint.X, Y: calibrationPoint.Y}
-		pointsIndexMap[calibrationPoint.Id] = index
-		index++
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if generation < a.interpolatorsBuilt {
+		return // the calibration changed again while building and newer interpolators are stored
 	}
+	a.interpolatorsBuilt = generation
+	a.linearInterpolators, a.channelInterpolators = linearInterpolators, channelInterpolators
+}
 
-	for _, fixture := range a.fixtures {
-		calibrated := true
-		for _, calibrationPoint := range a.calibrationPoints {
-			_, exists := fixture.Calibration[calibrationPoint.Id]
+// calculateLayerInterpolators builds the pan/tilt and channel interpolators for the calibration points of one layer.
+func calculateLayerInterpolators(fixtures map[string]Fixture, layer string, calibrationPoints map[string]CalibrationPoint) (map[string]PanTiltInterpolator, map[string]map[string]PanTiltInterpolator) {
+	interpolators := make(map[string]PanTiltInterpolator)
+	channels := make(map[string]map[string]PanTiltInterpolator)
+
+	// The camera to floor mapping is shared by all fixtures using homography interpolation
+	videoPoints := make([]Point, 0, len(calibrationPoints))
+	floorPoints := make([]Point, 0, len(calibrationPoints))
+	for _, calibrationPoint := range calibrationPoints {
+		if calibrationPoint.HasFloorPosition {
+			videoPoints = append(videoPoints, Point{X: calibrationPoint.X, Y: calibrationPoint.Y})
+			floorPoints = append(floorPoints, Point{X: calibrationPoint.FloorX, Y: calibrationPoint.FloorY})
+		}
+	}
+	homography, homographyErr := fitHomography(videoPoints, floorPoints)
+
+	// Iterate the points in a fixed order, so fixtures calibrated for the same points share a mesh
+	ids := make([]string, 0, len(calibrationPoints))
+	for id := range calibrationPoints {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	meshes := make(map[string]*TriangleMesh)
+
+	for _, fixture := range fixtures {
+		// Only use the points this fixture is calibrated for, so a point it can not reach does not
+		// stop it from following everywhere else
+		pointIds := make([]string, 0, len(calibrationPoints))
+		points := make([]Point, 0, len(calibrationPoints))
+		panValues := make([]float64, 0, len(calibrationPoints))
+		tiltValues := make([]float64, 0, len(calibrationPoints))
+		for _, id := range ids {
+			calibrationPoint := calibrationPoints[id]
+			calibration, exists := fixture.Calibration[calibrationPoint.Id]
 			if !exists {
-				calibrated = false
-				break
+				continue
 			}
+			pointIds = append(pointIds, id)
+			points = append(points, Point{X: calibrationPoint.X, Y: calibrationPoint.Y})
+			panValues = append(panValues, float64(calibration.Pan))
+			tiltValues = append(tiltValues, float64(calibration.Tilt))
 		}
 
-		if !calibrated {
+		if len(points) < 3 && fixture.Interpolation == InterpolationHomography && fixture.KnownPose != nil && homographyErr == nil {
+			// Too few points to triangulate, the known pose aims the fixture on its own
+			interp, err := NewHomographyPanTiltInterpolator(nil, nil, nil, poseSamples(fixture, calibrationPoints), fixture.KnownPose, homography, videoPoints, -1.0)
+			if err != nil {
+				LogError("Failed to create interpolator for fixture %s (%s): %s", fixture.Id, fixture.Name, err.Error())
+				continue
+			}
+			interpolators[fixture.Id] = interp
+			LogInfo("Built interpolator for fixture %s (%s) on layer %s from its known pose", fixture.Id, fixture.Name, layer)
+			continue
+		}
+		if len(points) == 0 {
 			continue
 		}
+		if len(points) < len(calibrationPoints) {
+			LogInfo("Fixture %s (%s) is calibrated for %d of %d point(s) on layer %s", fixture.Id, fixture.Name, len(points), len(calibrationPoints), layer)
+		}
+
+		meshKey := strings.Join(pointIds, ",")
+		mesh, exists := meshes[meshKey]
+		if !exists {
+			var err error
+			mesh, err = NewTriangleMesh(points)
+			if err != nil {
+				LogError("Failed to triangulate calibration points for fixture %s (%s): %s", fixture.Id, fixture.Name, err.Error())
+				continue
+			}
+			meshes[meshKey] = mesh
+		}
 
-		panValues := make([]float64, len(a.calibrationPoints))
-		tiltValues := make([]float64, len(a.calibrationPoints))
-		for _, calibrationPoint := range a.calibrationPoints {
-			panValues[pointsIndexMap[calibrationPoint.Id]] = float64(fixture.Calibration[calibrationPoint.Id].Pan)
-			tiltValues[pointsIndexMap[calibrationPoint.Id]] = float64(fixture.Calibration[calibrationPoint.Id].Tilt)
+		if turn, wraps := panTurn(fixture); wraps {
+			panValues = unwrapPanValues(mesh, panValues, turn)
 		}
 
-		interp, err := NewLinear2DPanTiltInterpolator(points, panValues, tiltValues, -1.0)
+		var interp PanTiltInterpolator
+		var err error
+		if fixture.Interpolation == InterpolationHomography {
+			if homographyErr != nil {
+				LogError("Failed to create interpolator for fixture %s (%s): %s", fixture.Id, fixture.Name, homographyErr.Error())
+				continue
+			}
+			var homographyInterp *HomographyPanTiltInterpolator
+			homographyInterp, err = NewHomographyPanTiltInterpolator(mesh, panValues, tiltValues, poseSamples(fixture, calibrationPoints), fixture.KnownPose, homography, videoPoints, -1.0)
+			if err == nil {
+				interp = homographyInterp
+			}
+		} else {
+			interp, err = newPanTiltInterpolator(fixture.Interpolation, mesh, panValues, tiltValues, -1.0, fixture.EdgeMode)
+		}
 		if err != nil {
 			LogError("Failed to create interpolator for fixture %s (%s): %s", fixture.Id, fixture.Name, err.Error())
 			continue
 		}
 
-		a.linearInterpolators[fixture.Id] = interp
-		LogInfo("Built interpolator for fixture %s (%s) with %d calibration point(s)", fixture.Id, fixture.Name, len(points))
+		interpolators[fixture.Id] = interp
+		channels[fixture.Id] = channelInterpolators(fixture, mesh, pointIds)
+		LogInfo("Built interpolator for fixture %s (%s) on layer %s with %d calibration point(s)", fixture.Id, fixture.Name, layer, len(points))
 	}
+
+	return interpolators, channels
 }
 
-func (a *App) SetMouseForAllFixtures(x float64, y float64) {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-	for _, fixture := range a.fixtures {
-		interp, exists := a.linearInterpolators[fixture.Id]
-		if !exists {
+// poseSamples returns the calibration of the fixture at the calibration points with a floor position.
+func poseSamples(fixture Fixture, calibrationPoints map[string]CalibrationPoint) []PoseSample {
+	samples := make([]PoseSample, 0, len(fixture.Calibration))
+	for _, calibrationPoint := range calibrationPoints {
+		calibration, exists := fixture.Calibration[calibrationPoint.Id]
+		if !exists || !calibrationPoint.HasFloorPosition {
 			continue
 		}
+		samples = append(samples, PoseSample{
+			Id:    calibrationPoint.Id,
+			Floor: Point{X: calibrationPoint.FloorX, Y: calibrationPoint.FloorY},
+			Pan:   float64(calibration.Pan),
+			Tilt:  float64(calibration.Tilt),
+		})
+	}
+	return samples
+}
 
-		pan, tilt, err := interp.Interpolate(delaunay.Point{X: x, Y: y})
+// GetFixturePoses solves the mounting pose of every fixture from its calibration at the points of the
+// active layer with a floor position, and reports how far off each calibration is from the solved pose.
+func (a *App) GetFixturePoses() map[string]FixturePoseReport {
+	a.mu.Lock()
+	calibrationPoints := a.calibrationPointsByLayer()[a.activeLayer]
+	fixtures := maps.Clone(a.fixtures)
+	a.mu.Unlock()
+
+	reports := make(map[string]FixturePoseReport, len(fixtures))
+	for id, fixture := range fixtures {
+		solution, err := solveFixturePose(poseSamples(fixture, calibrationPoints), fixture.KnownPose)
 		if err != nil {
-			runtime.LogError(a.ctx, err.Error())
+			reports[id] = FixturePoseReport{Error: err.Error()}
 			continue
 		}
 
-		if pan == interp.fillValue || tilt == interp.fillValue {
+		report := FixturePoseReport{
+			Solved:           true,
+			Pose:             solution.Pose,
+			PanRangeDegrees:  solution.Pose.PanRangeDegrees(),
+			TiltRangeDegrees: solution.Pose.TiltRangeDegrees(),
+			RMSError:         solution.RMSError,
+			Residuals:        make(map[string]PoseResidual, len(solution.Residuals)),
+		}
+		for _, residual := range solution.Residuals {
+			report.Residuals[residual.Id] = residual
+			if residual.Outlier {
+				LogInfo("Calibration of fixture %s (%s) at point %s (%s) is off by pan %.0f, tilt %.0f from the solved pose, it may be mis-clicked",
+					fixture.Id, fixture.Name, residual.Id, calibrationPoints[residual.Id].Name, residual.Pan, residual.Tilt)
+			}
+		}
+		reports[id] = report
+	}
+	return reports
+}
+
+// SetMouseForAllFixtures moves the fixtures following the default target, which are all fixtures
+// unless some follow other targets.
+func (a *App) SetMouseForAllFixtures(x float64, y float64) {
+	a.SetTargetPosition(DefaultTarget, x, y)
+}
+
+// setTargetPosition moves the fixtures following target to a position on the video.
+func (a *App) setTargetPosition(target string, x float64, y float64) {
+	a.targetPositions[target] = Point{X: x, Y: y}
+	for _, fixture := range a.fixtures {
+		if fixture.target() != target || !a.following(fixture.Id) {
+			continue
+		}
+		a.pointFixtureAt(fixture, Point{X: x, Y: y})
+	}
+	a.dmxChanged()
+}
+
+// pointFixtureAt points a fixture at a position on the video, setting its pan/tilt and the channels
+// calibrated for the position.
+func (a *App) pointFixtureAt(fixture Fixture, p Point) {
+	layer := a.layerAt(p)
+	panTilt, ok := a.panTiltAt(fixture, layer, p)
+	if !ok {
+		return
+	}
+	a.setPanTiltTarget(fixture.Id, panTilt.Pan, panTilt.Tilt)
+
+	for _, channel := range fixture.Channels {
+		channelInterp, exists := a.channelInterpolators[layer][fixture.Id][channel.Id]
+		if !exists {
+			continue
+		}
+		value, _, err := channelInterp.Interpolate(delaunay.Point{X: p.X, Y: p.Y})
+		if err != nil {
 			continue
 		}
+		a.setChannelForFixture(fixture, channel, int(math.Round(value)))
+	}
+}
 
-		a.setPanTiltForFixture(fixture.Id, int(pan), int(tilt))
+// panTiltAt returns the trimmed pan/tilt pointing a fixture at a position on the video, false if the
+// fixture is not calibrated for the position.
+func (a *App) panTiltAt(fixture Fixture, layer string, p Point) (PanTilt, bool) {
+	interp, exists := a.linearInterpolators[layer][fixture.Id]
+	if !exists {
+		return PanTilt{}, false
 	}
+
+	pan, tilt, err := interp.Interpolate(delaunay.Point{X: p.X, Y: p.Y})
+	if errors.Is(err, errOutsideCalibration) {
+		return PanTilt{}, false
+	} else if err != nil {
+		runtime.LogError(a.ctx, err.Error())
+		return PanTilt{}, false
+	}
+
+	// The trimmed pan is wrapped before it is clamped, a trim past the end of the range turns the fixture
+	// one turn back. wrapPan clamps if no turn is in range.
+	trim := a.fixtureControls[fixture.Id].Trim
+	pan, tilt = pan+float64(trim.Pan), clampPanTiltValue(tilt+float64(trim.Tilt))
+
+	if turn, wraps := panTurn(fixture); wraps {
+		current := pan
+		if last, sent := a.lastPanTilt[fixture.Id]; sent {
+			current = float64(last.Pan)
+		}
+		pan = wrapPan(pan, current, turn)
+	} else {
+		pan = clampPanTiltValue(pan)
+	}
+	return PanTilt{Pan: int(pan), Tilt: int(tilt)}, true
 }
 
 func (a *App) SetPanTiltForFixture(fixtureId string, pan int, tilt int) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	delete(a.motionFilters, fixtureId) // set directly, e.g. when calibrating, the filter restarts from here
 	a.setPanTiltForFixture(fixtureId, pan, tilt)
+	a.dmxChanged()
 }
 
 func (a *App) setPanTiltForFixture(fixtureId string, pan int, tilt int) {
@@ -288,30 +567,23 @@ func (a *App) SetLastVideoSource(id, label string) {
 	a.updateLastVideoSource(id, label)
 }
 
-func (a *App) GetTriangles() []Triangle {
+// GetTriangles returns the triangulation of every fixture, by fixture id. A fixture's triangulation
+// only covers the calibration points it is calibrated for, which is the area it can follow in.
+func (a *App) GetTriangles() map[string][]Triangle {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Grab the first available interpolator (all share the same geometry)
-	for _, interp := range a.linearInterpolators {
-		numEdges := len(interp.tri.Triangles)
-		numTriangles := numEdges / 3
-		triangles := make([]Triangle, 0, numTriangles)
-		for i := 0; i < numTriangles; i++ {
-			idx := i * 3
-			a := interp.points[interp.tri.Triangles[idx]]
-			b := interp.points[interp.tri.Triangles[idx+1]]
-			c := interp.points[interp.tri.Triangles[idx+2]]
-			triangles = append(triangles, Triangle{
-				Ax: a.X, Ay: a.Y,
-				Bx: b.X, By: b.Y,
-				Cx: c.X, Cy: c.Y,
-			})
+	triangles := make(map[string][]Triangle)
+	for layer, interpolators := range a.linearInterpolators {
+		for fixtureId, interp := range interpolators {
+			for _, triangle := range interp.Triangles() {
+				triangle.Layer = layer
+				triangles[fixtureId] = append(triangles[fixtureId], triangle)
+			}
 		}
-		return triangles
 	}
 
-	return []Triangle{}
+	return triangles
 }
 
 func (a *App) OpenLogFile() error {
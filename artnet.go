@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	artNetPort            = 6454
+	artNetProtocolVersion = 14
+
+	artNetOpPoll      = 0x2000
+	artNetOpPollReply = 0x2100
+	artNetOpDmx       = 0x5000
+
+	// artNetPollInterval is how often nodes are polled, the Art-Net spec asks for 2.5 to 3 seconds.
+	artNetPollInterval = 3 * time.Second
+	// artNetNodeTimeout is how long a node is listed after its last reply.
+	artNetNodeTimeout = 3 * artNetPollInterval
+
+	// artNetMaxPortAddress is the highest Art-Net port-address (15 bits: net, sub-net and universe).
+	artNetMaxPortAddress = 0x7FFF
+)
+
+var artNetID = []byte("Art-Net\x00")
+
+// artNetUniverse is an Art-Net universe being sent.
+type artNetUniverse struct {
+	sequence     byte
+	broadcast    bool
+	destinations []*net.UDPAddr
+}
+
+// artNetNodeReply is a node port group from an ArtPollReply, a node with more than four ports sends
+// one reply for each group of ports.
+type artNetNodeReply struct {
+	node ArtNetNode
+	seen time.Time
+}
+
+// artNetOutput sends universes with Art-Net. Universe n is sent on port-address n-1, so Följe universe
+// 1 is Art-Net 0:0:0, matching how most consoles map sACN universes to Art-Net.
+type artNetOutput struct {
+	conn      *net.UDPConn
+	broadcast *net.UDPAddr
+	universes map[uint16]*artNetUniverse
+	stop      chan bool
+
+	nodesMu sync.Mutex // protects nodes, which are updated by the receive loop
+	nodes   map[string]artNetNodeReply
+}
+
+func newArtNetOutput(ip string) (*artNetOutput, error) {
+	localIP := net.ParseIP(ip).To4()
+	if localIP == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 address", ip)
+	}
+
+	// Nodes reply to polls on the Art-Net port, without it the output works but finds no nodes
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{Port: artNetPort})
+	if err != nil {
+		LogError("Failed to listen on the Art-Net port, Art-Net nodes will not be found: %s", err.Error())
+		conn, err = net.ListenUDP("udp4", &net.UDPAddr{IP: localIP})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	o := &artNetOutput{
+		conn:      conn,
+		broadcast: &net.UDPAddr{IP: broadcastAddress(localIP), Port: artNetPort},
+		universes: make(map[uint16]*artNetUniverse),
+		stop:      make(chan bool),
+		nodes:     make(map[string]artNetNodeReply),
+	}
+	go o.receiveLoop()
+	go o.pollLoop()
+
+	LogInfo("Created Art-Net output on IP %s (broadcast: %s)", ip, o.broadcast.IP)
+	return o, nil
+}
+
+// broadcastAddress returns the directed broadcast address of the network ip is on.
+func broadcastAddress(ip net.IP) net.IP {
+	itf, err := interfaceWithIP(ip.String())
+	if err == nil {
+		addrs, _ := itf.Addrs()
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.Equal(ip) || len(ipNet.Mask) != net.IPv4len {
+				continue
+			}
+			broadcast := make(net.IP, net.IPv4len)
+			for i := range broadcast {
+				broadcast[i] = ipNet.IP.To4()[i] | ^ipNet.Mask[i]
+			}
+			return broadcast
+		}
+	}
+	return net.IPv4bcast
+}
+
+func (o *artNetOutput) StartUniverse(uni uint16) error {
+	if uni < 1 || uni > artNetMaxPortAddress+1 {
+		return fmt.Errorf("universe %d can not be sent with Art-Net, should be between 1 and %d", uni, artNetMaxPortAddress+1)
+	}
+	o.universes[uni] = &artNetUniverse{}
+	return nil
+}
+
+func (o *artNetOutput) StopUniverse(uni uint16) {
+	delete(o.universes, uni)
+}
+
+func (o *artNetOutput) SetRouting(uni uint16, multicast bool, destinations []string) {
+	universe, exists := o.universes[uni]
+	if !exists {
+		return
+	}
+
+	universe.broadcast = multicast
+	universe.destinations = make([]*net.UDPAddr, 0, len(destinations))
+	for _, dest := range destinations {
+		addr, err := resolveDestination(dest, artNetPort)
+		if err != nil {
+			LogError("Failed to add destination %s to universe %d: %s", dest, uni, err.Error())
+			continue
+		}
+		universe.destinations = append(universe.destinations, addr)
+	}
+}
+
+// resolveDestination resolves a destination, which may have a port to send to instead of port.
+func resolveDestination(dest string, port int) (*net.UDPAddr, error) {
+	if _, _, err := net.SplitHostPort(dest); err != nil {
+		dest = net.JoinHostPort(dest, strconv.Itoa(port))
+	}
+	return net.ResolveUDPAddr("udp4", dest)
+}
+
+func (o *artNetOutput) Send(uni uint16, startCode byte, data DMXData, priority uint8) error {
+	if startCode != startCodeNull {
+		return nil // ArtDmx only carries levels
+	}
+
+	universe, exists := o.universes[uni]
+	if !exists {
+		return errors.New("universe is not started")
+	}
+
+	// Sequence 0 disables reordering on the node, so it wraps from 255 to 1
+	universe.sequence = universe.sequence%255 + 1
+	p := artDmxPacket(uni-1, universe.sequence, data)
+
+	var errs []error
+	destinations := universe.destinations
+	if universe.broadcast {
+		destinations = append([]*net.UDPAddr{o.broadcast}, destinations...)
+	} else if len(destinations) == 0 {
+		// Art-Net asks controllers to unicast to the nodes outputting the universe
+		destinations = o.nodeAddresses(uni)
+	}
+	for _, dest := range destinations {
+		if _, err := o.conn.WriteToUDP(p, dest); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Terminate does nothing, Art-Net nodes notice a stopped universe by it timing out.
+func (o *artNetOutput) Terminate(uni uint16) {}
+
+func (o *artNetOutput) Close() {
+	close(o.stop)
+	o.conn.Close()
+}
+
+func artNetHeader(opCode uint16) *bytes.Buffer {
+	p := bytes.NewBuffer(make([]byte, 0, 530))
+	p.Write(artNetID)
+	binary.Write(p, binary.LittleEndian, opCode)
+	binary.Write(p, binary.BigEndian, uint16(artNetProtocolVersion))
+	return p
+}
+
+func artDmxPacket(portAddress uint16, sequence byte, data DMXData) []byte {
+	p := artNetHeader(artNetOpDmx)
+	p.WriteByte(sequence)
+	p.WriteByte(0)                        // physical input port, informative only
+	p.WriteByte(byte(portAddress & 0xFF)) // sub-net and universe
+	p.WriteByte(byte(portAddress >> 8))   // net
+	binary.Write(p, binary.BigEndian, uint16(len(data)))
+	p.Write(data[:])
+	return p.Bytes()
+}
+
+func artPollPacket() []byte {
+	p := artNetHeader(artNetOpPoll)
+	p.WriteByte(0) // flags: only reply to polls
+	p.WriteByte(0) // diagnostics priority, diagnostics are not requested
+	return p.Bytes()
+}
+
+func (o *artNetOutput) pollLoop() {
+	ticker := time.NewTicker(artNetPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := o.conn.WriteToUDP(artPollPacket(), o.broadcast); err != nil {
+			LogDebug("Failed to send ArtPoll: %s", err.Error())
+		}
+
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *artNetOutput) receiveLoop() {
+	buffer := make([]byte, 1024)
+	for {
+		n, _, err := o.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			LogDebug("Failed to receive Art-Net packet: %s", err.Error())
+			continue
+		}
+
+		reply, key, ok := parseArtPollReply(buffer[:n])
+		if !ok {
+			continue // not a poll reply, e.g. our own poll or another controller's data
+		}
+
+		o.nodesMu.Lock()
+		if _, known := o.nodes[key]; !known {
+			LogInfo("Found Art-Net node %s (%s) outputting universes %v", reply.ShortName, reply.Ip, reply.Universes)
+		}
+		o.nodes[key] = artNetNodeReply{node: reply, seen: time.Now()}
+		o.nodesMu.Unlock()
+	}
+}
+
+// parseArtPollReply parses an ArtPollReply, returning the node and a key for the replying port group.
+func parseArtPollReply(p []byte) (ArtNetNode, string, bool) {
+	if len(p) < 194 || !bytes.Equal(p[:8], artNetID) || binary.LittleEndian.Uint16(p[8:10]) != artNetOpPollReply {
+		return ArtNetNode{}, "", false
+	}
+
+	ip := net.IP(p[10:14]).String()
+	netSwitch, subSwitch := uint16(p[18]&0x7F), uint16(p[19]&0x0F)
+	ports := min(int(p[173]), 4)
+	bindIndex := 0
+	if len(p) > 211 {
+		bindIndex = int(p[211])
+	}
+
+	node := ArtNetNode{
+		Ip:        ip,
+		ShortName: artNetString(p[26:44]),
+		LongName:  artNetString(p[44:108]),
+		Universes: make([]uint16, 0, ports),
+	}
+	for i := 0; i < ports; i++ {
+		if p[174+i]&0x80 == 0 {
+			continue // the port can not output DMX
+		}
+		portAddress := netSwitch<<8 | subSwitch<<4 | uint16(p[190+i]&0x0F)
+		node.Universes = append(node.Universes, portAddress+1)
+	}
+	return node, fmt.Sprintf("%s/%d", ip, bindIndex), true
+}
+
+func artNetString(field []byte) string {
+	if end := bytes.IndexByte(field, 0); end >= 0 {
+		field = field[:end]
+	}
+	return strings.TrimSpace(string(field))
+}
+
+// foundNodes returns the nodes which replied recently, with the universes of all their port groups.
+func (o *artNetOutput) foundNodes() []ArtNetNode {
+	o.nodesMu.Lock()
+	defer o.nodesMu.Unlock()
+
+	byIp := make(map[string]*ArtNetNode)
+	for key, reply := range o.nodes {
+		if time.Since(reply.seen) > artNetNodeTimeout {
+			delete(o.nodes, key)
+			continue
+		}
+		node, exists := byIp[reply.node.Ip]
+		if !exists {
+			node = &ArtNetNode{Ip: reply.node.Ip, ShortName: reply.node.ShortName, LongName: reply.node.LongName}
+			byIp[reply.node.Ip] = node
+		}
+		node.Universes = append(node.Universes, reply.node.Universes...)
+	}
+
+	nodes := make([]ArtNetNode, 0, len(byIp))
+	for _, node := range byIp {
+		sort.Slice(node.Universes, func(i, j int) bool { return node.Universes[i] < node.Universes[j] })
+		nodes = append(nodes, *node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Ip < nodes[j].Ip })
+	return nodes
+}
+
+// nodeAddresses returns the addresses of the nodes outputting a universe.
+func (o *artNetOutput) nodeAddresses(uni uint16) []*net.UDPAddr {
+	addresses := make([]*net.UDPAddr, 0)
+	for _, node := range o.foundNodes() {
+		for _, universe := range node.Universes {
+			if universe == uni {
+				addresses = append(addresses, &net.UDPAddr{IP: net.ParseIP(node.Ip), Port: artNetPort})
+				break
+			}
+		}
+	}
+	return addresses
+}
+
+// GetArtNetNodes returns the Art-Net nodes found on the network, empty if no universe uses Art-Net.
+func (a *App) GetArtNetNodes() []ArtNetNode {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	output, ok := a.outputs[ProtocolArtNet].(*artNetOutput)
+	if !ok {
+		return []ArtNetNode{}
+	}
+	return output.foundNodes()
+}
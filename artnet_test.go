@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestArtNetOutput(t *testing.T) *artNetOutput {
+	t.Helper()
+	output, err := newArtNetOutput("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(output.Close)
+	return output
+}
+
+func TestArtDmxPacket(t *testing.T) {
+	output := newTestArtNetOutput(t)
+	node := listenUDP(t)
+
+	const uni = 0x1235 // port-address 0x1234: net 0x12, sub-net 3, universe 4
+	if err := output.StartUniverse(uni); err != nil {
+		t.Fatal(err)
+	}
+	output.SetRouting(uni, false, []string{node.LocalAddr().String()})
+
+	data := DMXData{}
+	data[0], data[511] = 10, 20
+	for sequence := byte(1); sequence <= 2; sequence++ {
+		if err := output.Send(uni, startCodeNull, data, 100); err != nil {
+			t.Fatal(err)
+		}
+		p := receiveUDP(t, node, time.Second)
+		if len(p) != 18+512 {
+			t.Fatalf("received %d bytes, want %d", len(p), 18+512)
+		}
+		if !bytes.Equal(p[:8], artNetID) || binary.LittleEndian.Uint16(p[8:10]) != artNetOpDmx || binary.BigEndian.Uint16(p[10:12]) != artNetProtocolVersion {
+			t.Errorf("header %x, want ArtDmx of protocol version %d", p[:12], artNetProtocolVersion)
+		}
+		if p[12] != sequence {
+			t.Errorf("sequence %d, want %d", p[12], sequence)
+		}
+		if p[14] != 0x34 || p[15] != 0x12 {
+			t.Errorf("SubUni %#x and Net %#x, want 0x34 and 0x12", p[14], p[15])
+		}
+		if length := binary.BigEndian.Uint16(p[16:18]); length != 512 {
+			t.Errorf("length %d, want 512", length)
+		}
+		if p[18] != 10 || p[18+511] != 20 {
+			t.Errorf("data starts %d and ends %d, want 10 and 20", p[18], p[18+511])
+		}
+	}
+
+	// Sequence 0 turns off reordering on the node, so it is skipped when wrapping
+	output.universes[uni].sequence = 255
+	if err := output.Send(uni, startCodeNull, data, 100); err != nil {
+		t.Fatal(err)
+	}
+	if p := receiveUDP(t, node, time.Second); len(p) < 13 || p[12] != 1 {
+		t.Errorf("sequence after 255 is %v, want 1", p[12:13])
+	}
+
+	// ArtDmx only carries levels
+	if err := output.Send(uni, startCodePerAddressPriority, data, 100); err != nil {
+		t.Fatal(err)
+	}
+	if p := receiveUDP(t, node, 50*time.Millisecond); p != nil {
+		t.Errorf("sent %d bytes for per-address priority", len(p))
+	}
+}
+
+// artPollReply builds an ArtPollReply as sent by a node, with the fields Följe reads at their offsets.
+func artPollReply(ip [4]byte, netSwitch, subSwitch byte, shortName, longName string, portTypes, swOut []byte, bindIndex byte) []byte {
+	p := make([]byte, 239)
+	copy(p, artNetID)
+	binary.LittleEndian.PutUint16(p[8:10], artNetOpPollReply)
+	copy(p[10:14], ip[:])
+	binary.LittleEndian.PutUint16(p[14:16], artNetPort)
+	p[18], p[19] = netSwitch, subSwitch
+	copy(p[26:44], shortName)
+	copy(p[44:108], longName)
+	p[173] = byte(len(portTypes))
+	copy(p[174:178], portTypes)
+	copy(p[190:194], swOut)
+	p[211] = bindIndex
+	return p
+}
+
+func TestParseArtPollReply(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		want   ArtNetNode
+		key    string
+	}{
+		{
+			name:   "four output ports",
+			packet: artPollReply([4]byte{10, 0, 0, 5}, 1, 2, "Node", "Node 4 port", []byte{0x80, 0x80, 0x80, 0x80}, []byte{0, 1, 2, 3}, 1),
+			want:   ArtNetNode{Ip: "10.0.0.5", ShortName: "Node", LongName: "Node 4 port", Universes: []uint16{0x121, 0x122, 0x123, 0x124}},
+			key:    "10.0.0.5/1",
+		},
+		{
+			name:   "input port and padded names",
+			packet: artPollReply([4]byte{10, 0, 0, 6}, 0, 0, " Gate ", "Gateway", []byte{0x40, 0x80}, []byte{0, 5}, 2),
+			want:   ArtNetNode{Ip: "10.0.0.6", ShortName: "Gate", LongName: "Gateway", Universes: []uint16{6}},
+			key:    "10.0.0.6/2",
+		},
+		{
+			name:   "switches use only their low bits",
+			packet: artPollReply([4]byte{10, 0, 0, 7}, 0xFF, 0xFF, "N", "N", []byte{0x80}, []byte{0xFF}, 0),
+			want:   ArtNetNode{Ip: "10.0.0.7", ShortName: "N", LongName: "N", Universes: []uint16{0x7FFF + 1}},
+			key:    "10.0.0.7/0",
+		},
+	}
+	for _, test := range tests {
+		node, key, ok := parseArtPollReply(test.packet)
+		if !ok {
+			t.Errorf("%s: not parsed", test.name)
+			continue
+		}
+		if !reflect.DeepEqual(node, test.want) || key != test.key {
+			t.Errorf("%s: parsed %+v with key %s, want %+v with key %s", test.name, node, key, test.want, test.key)
+		}
+	}
+
+	// Replies from nodes predating the bind index are shorter
+	short := artPollReply([4]byte{10, 0, 0, 8}, 0, 0, "Old", "Old node", []byte{0x80}, []byte{0}, 0)[:207]
+	if node, key, ok := parseArtPollReply(short); !ok || key != "10.0.0.8/0" || !reflect.DeepEqual(node.Universes, []uint16{1}) {
+		t.Errorf("short reply parsed %+v with key %s, ok %v", node, key, ok)
+	}
+
+	wrongOpCode := artPollReply([4]byte{10, 0, 0, 9}, 0, 0, "N", "N", nil, nil, 0)
+	binary.LittleEndian.PutUint16(wrongOpCode[8:10], artNetOpPoll)
+	for name, p := range map[string][]byte{
+		"truncated":     artPollReply([4]byte{10, 0, 0, 9}, 0, 0, "N", "N", nil, nil, 0)[:100],
+		"wrong op code": wrongOpCode,
+		"not Art-Net":   append([]byte("Art-Nix\x00"), wrongOpCode[8:]...),
+	} {
+		if _, _, ok := parseArtPollReply(p); ok {
+			t.Errorf("%s: parsed as a poll reply", name)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+// channelInterpolators builds an interpolator for every extra channel of the fixture over mesh, whose
+// points are the calibration points with the given ids. The channel value is interpolated as pan with
+// the fixture's interpolation, except homography which falls back to linear as a channel has no pose.
+func channelInterpolators(fixture Fixture, mesh *TriangleMesh, pointIds []string) map[string]PanTiltInterpolator {
+	mode := fixture.Interpolation
+	if mode == InterpolationHomography {
+		mode = InterpolationLinear
+	}
+
+	interpolators := make(map[string]PanTiltInterpolator, len(fixture.Channels))
+	for _, channel := range fixture.Channels {
+		values := make([]float64, len(pointIds))
+		for i, id := range pointIds {
+			value, exists := fixture.Calibration[id].Channels[channel.Id]
+			if !exists {
+				value = channel.Default
+			}
+			values[i] = float64(value)
+		}
+
+		interp, err := newPanTiltInterpolator(mode, mesh, values, values, -1.0, fixture.EdgeMode)
+		if err != nil {
+			LogError("Failed to create interpolator for channel %s of fixture %s (%s): %s", channel.Name, fixture.Id, fixture.Name, err.Error())
+			continue
+		}
+		interpolators[channel.Id] = interp
+	}
+	return interpolators
+}
+
+func (a *App) setChannelForFixture(fixture Fixture, channel FixtureChannel, value int) {
+	value = int(clampPanTiltValue(float64(value)))
+	data := a.universeDMXData[fixture.Universe]
+
+	if channel.Address >= 0 && channel.Address < 512 {
+		data[channel.Address] = byte(value / 256)
+	}
+	if channel.FineAddress >= 0 && channel.FineAddress < 512 {
+		data[channel.FineAddress] = byte(value % 256)
+	}
+
+	a.universeDMXData[fixture.Universe] = data
+}
+
+// SetChannelForFixture sets an extra channel of a fixture directly, e.g. while calibrating it.
+func (a *App) SetChannelForFixture(fixtureId string, channelId string, value int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	fixture, exists := a.fixtures[fixtureId]
+	if !exists {
+		LogError("Tried to set channel for non-existing fixture: %s", fixtureId)
+		return
+	}
+
+	for _, channel := range fixture.Channels {
+		if channel.Id == channelId {
+			a.setChannelForFixture(fixture, channel, value)
+			a.dmxChanged()
+			return
+		}
+	}
+	LogError("Tried to set non-existing channel %s for fixture %s (%s)", channelId, fixture.Id, fixture.Name)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"math"
+
+	"github.com/fogleman/delaunay"
+)
+
+// CloughTocher2DPanTiltInterpolator interpolates with Clough-Tocher patches: every triangle is split
+// at its centroid into three cubic Bézier patches which join with continuous first derivatives, so
+// the beam does not change speed abruptly when the cursor crosses a triangle edge.
+// Based on the construction used by scipy's CloughTocher2DInterpolator.
+type CloughTocher2DPanTiltInterpolator struct {
+	*Linear2DPanTiltInterpolator
+	panGradients  []delaunay.Point
+	tiltGradients []delaunay.Point
+}
+
+func NewCloughTocher2DPanTiltInterpolator(mesh *TriangleMesh, panValues []float64, tiltValues []float64, fillValue float64, edgeMode string) (*CloughTocher2DPanTiltInterpolator, error) {
+	linear, err := NewLinear2DPanTiltInterpolator(mesh, panValues, tiltValues, fillValue, edgeMode)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbours := make([]map[int]bool, len(linear.points))
+	for i := range neighbours {
+		neighbours[i] = make(map[int]bool)
+	}
+	for e := range linear.tri.Triangles {
+		from, to := linear.tri.Triangles[e], linear.tri.Triangles[nextHalfedge(e)]
+		neighbours[from][to] = true
+		neighbours[to][from] = true
+	}
+
+	return &CloughTocher2DPanTiltInterpolator{
+		Linear2DPanTiltInterpolator: linear,
+		panGradients:                estimateGradients(linear.points, neighbours, panValues),
+		tiltGradients:               estimateGradients(linear.points, neighbours, tiltValues),
+	}, nil
+}
+
+// estimateGradients estimates the gradient at every point by an inverse distance weighted least
+// squares fit of a plane through the point and its neighbours in the triangulation.
+func estimateGradients(points []delaunay.Point, neighbours []map[int]bool, values []float64) []delaunay.Point {
+	gradients := make([]delaunay.Point, len(points))
+	for i, p := range points {
+		var sxx, sxy, syy, sxf, syf float64
+		for j := range neighbours[i] {
+			dx, dy := points[j].X-p.X, points[j].Y-p.Y
+			df := values[j] - values[i]
+			weight := 1 / (dx*dx + dy*dy)
+			sxx += weight * dx * dx
+			sxy += weight * dx * dy
+			syy += weight * dy * dy
+			sxf += weight * dx * df
+			syf += weight * dy * df
+		}
+
+		det := sxx*syy - sxy*sxy
+		if math.Abs(det) < 1e-12 {
+			continue // not enough neighbours to fit a plane, treat the point as flat
+		}
+		gradients[i] = delaunay.Point{
+			X: (syy*sxf - sxy*syf) / det,
+			Y: (sxx*syf - sxy*sxf) / det,
+		}
+	}
+	return gradients
+}
+
+func (interp *CloughTocher2DPanTiltInterpolator) Interpolate(point delaunay.Point) (float64, float64, error) {
+	triangle, err := interp.LocatePoint(point)
+	if err != nil {
+		return interp.extrapolate(point, interp.interpolateSmooth) // point is outside the convex hull
+	}
+
+	return interp.interpolateSmooth(point, triangle)
+}
+
+func (interp *CloughTocher2DPanTiltInterpolator) interpolateSmooth(point delaunay.Point, triangle int) (float64, float64, error) {
+	b1, b2, b3, err := interp.Barycentric(point, triangle)
+	if err != nil {
+		return interp.fillValue, interp.fillValue, err
+	}
+
+	g, err := interp.edgeDirections(triangle)
+	if err != nil {
+		return interp.fillValue, interp.fillValue, err
+	}
+
+	b := [3]float64{b1, b2, b3}
+	pan := interp.evaluatePatch(triangle, b, g, interp.panValues, interp.panGradients)
+	tilt := interp.evaluatePatch(triangle, b, g, interp.tiltValues, interp.tiltGradients)
+	return pan, tilt, nil
+}
+
+// edgeDirections returns, for the edge opposite each vertex, the direction along which the cross
+// derivative is kept linear. Using the direction between the centroids of the two triangles sharing
+// the edge makes neighbouring patches agree on it and keeps the interpolant affine invariant.
+func (interp *CloughTocher2DPanTiltInterpolator) edgeDirections(triangle int) ([3]float64, error) {
+	var g [3]float64
+	for k := 0; k < 3; k++ {
+		// The edge opposite vertex k starts at vertex k+1
+		opposite := interp.tri.Halfedges[3*triangle+(k+1)%3]
+		if opposite == -1 {
+			g[k] = -0.5 // on the hull, use the direction towards the centroid
+			continue
+		}
+
+		neighbour := opposite / 3
+		var centroid delaunay.Point
+		for v := 0; v < 3; v++ {
+			p := interp.points[interp.tri.Triangles[3*neighbour+v]]
+			centroid.X += p.X / 3
+			centroid.Y += p.Y / 3
+		}
+
+		c0, c1, c2, err := interp.Barycentric(centroid, triangle)
+		if err != nil {
+			return g, err
+		}
+
+		switch k {
+		case 0:
+			g[k] = (2*c2 + c1 - 1) / (2 - 3*c2 - 3*c1)
+		case 1:
+			g[k] = (2*c0 + c2 - 1) / (2 - 3*c0 - 3*c2)
+		case 2:
+			g[k] = (2*c1 + c0 - 1) / (2 - 3*c1 - 3*c0)
+		}
+	}
+	return g, nil
+}
+
+// evaluatePatch evaluates the Clough-Tocher patch of one value channel at barycentric coordinates b.
+func (interp *CloughTocher2DPanTiltInterpolator) evaluatePatch(triangle int, b [3]float64, g [3]float64, values []float64, gradients []delaunay.Point) float64 {
+	i1, i2, i3 := interp.tri.Triangles[3*triangle], interp.tri.Triangles[3*triangle+1], interp.tri.Triangles[3*triangle+2]
+	p1, p2, p3 := interp.points[i1], interp.points[i2], interp.points[i3]
+
+	e12x, e12y := p2.X-p1.X, p2.Y-p1.Y
+	e23x, e23y := p3.X-p2.X, p3.Y-p2.Y
+	e31x, e31y := p1.X-p3.X, p1.Y-p3.Y
+
+	f1, f2, f3 := values[i1], values[i2], values[i3]
+	df1, df2, df3 := gradients[i1], gradients[i2], gradients[i3]
+
+	df12 := df1.X*e12x + df1.Y*e12y
+	df21 := -(df2.X*e12x + df2.Y*e12y)
+	df23 := df2.X*e23x + df2.Y*e23y
+	df32 := -(df3.X*e23x + df3.Y*e23y)
+	df31 := df3.X*e31x + df3.Y*e31y
+	df13 := -(df1.X*e31x + df1.Y*e31y)
+
+	// Bézier control values, cIJKL is the coefficient of b1^I b2^J b3^K b4^L where b4 is the centroid
+	c3000 := f1
+	c2100 := (df12 + 3*c3000) / 3
+	c2010 := (df13 + 3*c3000) / 3
+	c0300 := f2
+	c1200 := (df21 + 3*c0300) / 3
+	c0210 := (df23 + 3*c0300) / 3
+	c0030 := f3
+	c1020 := (df31 + 3*c0030) / 3
+	c0120 := (df32 + 3*c0030) / 3
+
+	c2001 := (c2100 + c2010 + c3000) / 3
+	c0201 := (c1200 + c0300 + c0210) / 3
+	c0021 := (c1020 + c0120 + c0030) / 3
+
+	c0111 := (g[0]*(-c0300+3*c0210-3*c0120+c0030) + (-c0300 + 2*c0210 - c0120 + c0021 + c0201)) / 2
+	c1011 := (g[1]*(-c0030+3*c1020-3*c2010+c3000) + (-c0030 + 2*c1020 - c2010 + c2001 + c0021)) / 2
+	c1101 := (g[2]*(-c3000+3*c2100-3*c1200+c0300) + (-c3000 + 2*c2100 - c1200 + c2001 + c0201)) / 2
+
+	c1002 := (c1101 + c1011 + c2001) / 3
+	c0102 := (c1101 + c0111 + c0201) / 3
+	c0012 := (c1011 + c0111 + c0021) / 3
+
+	c0003 := (c1002 + c0102 + c0012) / 3
+
+	// Barycentric coordinates in the sub-triangle containing the point, one of b1-b3 is zero
+	minval := math.Min(b[0], math.Min(b[1], b[2]))
+	x1, x2, x3, x4 := b[0]-minval, b[1]-minval, b[2]-minval, 3*minval
+
+	return x1*x1*x1*c3000 + x2*x2*x2*c0300 + x3*x3*x3*c0030 + x4*x4*x4*c0003 +
+		3*(x1*x1*x2*c2100+x1*x1*x3*c2010+x1*x1*x4*c2001) +
+		3*(x2*x2*x1*c1200+x2*x2*x3*c0210+x2*x2*x4*c0201) +
+		3*(x3*x3*x1*c1020+x3*x3*x2*c0120+x3*x3*x4*c0021) +
+		3*(x4*x4*x1*c1002+x4*x4*x2*c0102+x4*x4*x3*c0012) +
+		6*(x1*x2*x4*c1101+x1*x3*x4*c1011+x2*x3*x4*c0111)
+}
+
+// newPanTiltInterpolator builds the interpolator selected by mode, defaulting to linear.
+func newPanTiltInterpolator(mode string, mesh *TriangleMesh, panValues []float64, tiltValues []float64, fillValue float64, edgeMode string) (PanTiltInterpolator, error) {
+	switch mode {
+	case InterpolationCloughTocher:
+		if len(mesh.points) < 3 {
+			return nil, errors.New("clough-tocher interpolation needs at least 3 calibration points")
+		}
+		return NewCloughTocher2DPanTiltInterpolator(mesh, panValues, tiltValues, fillValue, edgeMode)
+	default:
+		return NewLinear2DPanTiltInterpolator(mesh, panValues, tiltValues, fillValue, edgeMode)
+	}
+}
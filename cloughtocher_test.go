@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fogleman/delaunay"
+)
+
+// calibrationGrid is a 3x3 grid of calibration points with a few points moved off the grid, so the
+// triangulation is not symmetric.
+var calibrationGrid = []Point{
+	{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 1, Y: 0},
+	{X: 0, Y: 0.5}, {X: 0.45, Y: 0.55}, {X: 1, Y: 0.5},
+	{X: 0, Y: 1}, {X: 0.6, Y: 1}, {X: 1, Y: 1},
+}
+
+func newTestCloughTocher(t *testing.T, points []Point, pan, tilt func(x, y float64) float64) *CloughTocher2DPanTiltInterpolator {
+	t.Helper()
+	mesh, err := NewTriangleMesh(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	panValues := make([]float64, len(points))
+	tiltValues := make([]float64, len(points))
+	for i, p := range points {
+		panValues[i], tiltValues[i] = pan(p.X, p.Y), tilt(p.X, p.Y)
+	}
+	interp, err := NewCloughTocher2DPanTiltInterpolator(mesh, panValues, tiltValues, -1, EdgeModeNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return interp
+}
+
+// scipy's CloughTocher2DInterpolator reproduces linear data exactly, as its gradient estimate is exact
+// for it, so the plane is the reference value. Ours must do the same.
+func TestCloughTocherReproducesLinearData(t *testing.T) {
+	pan := func(x, y float64) float64 { return 1000 + 20000*x - 5000*y }
+	tilt := func(x, y float64) float64 { return 30000 - 8000*x + 12000*y }
+	interp := newTestCloughTocher(t, calibrationGrid, pan, tilt)
+
+	tests := []struct {
+		x, y      float64
+		pan, tilt float64
+	}{
+		{0.25, 0.25, 4750, 31000},
+		{0.5, 0.5, 8500, 32000},
+		{0.1, 0.9, -1500, 40000},
+		{0.75, 0.3, 14500, 27600},
+		{0.33, 0.66, 4300, 35280},
+		{1, 1, 16000, 34000},
+	}
+	for _, test := range tests {
+		gotPan, gotTilt, err := interp.Interpolate(delaunay.Point{X: test.x, Y: test.y})
+		if err != nil {
+			t.Fatalf("(%v, %v): %s", test.x, test.y, err)
+		}
+		if math.Abs(gotPan-test.pan) > 1e-6 || math.Abs(gotTilt-test.tilt) > 1e-6 {
+			t.Errorf("(%v, %v) = %v, %v, want %v, %v", test.x, test.y, gotPan, gotTilt, test.pan, test.tilt)
+		}
+	}
+}
+
+func TestCloughTocherInterpolatesCalibration(t *testing.T) {
+	pan := func(x, y float64) float64 { return 30000 + 10000*math.Sin(3*x) }
+	tilt := func(x, y float64) float64 { return 20000 + 15000*x*y }
+	interp := newTestCloughTocher(t, calibrationGrid, pan, tilt)
+
+	for _, p := range calibrationGrid {
+		gotPan, gotTilt, err := interp.Interpolate(delaunay.Point{X: p.X, Y: p.Y})
+		if err != nil {
+			t.Fatalf("(%v, %v): %s", p.X, p.Y, err)
+		}
+		if math.Abs(gotPan-pan(p.X, p.Y)) > 1e-6 || math.Abs(gotTilt-tilt(p.X, p.Y)) > 1e-6 {
+			t.Errorf("(%v, %v) = %v, %v, want the calibrated %v, %v", p.X, p.Y, gotPan, gotTilt, pan(p.X, p.Y), tilt(p.X, p.Y))
+		}
+	}
+}
+
+// With exact gradients the Clough-Tocher element reproduces quadratics, which checks the patch
+// construction taken from scipy independently of the gradient estimate.
+func TestCloughTocherPatchReproducesQuadratics(t *testing.T) {
+	f := func(x, y float64) float64 { return 3*x*x - 2*x*y + y*y + x - 4*y + 7 }
+	gradient := func(x, y float64) delaunay.Point { return delaunay.Point{X: 6*x - 2*y + 1, Y: -2*x + 2*y - 4} }
+	interp := newTestCloughTocher(t, calibrationGrid, f, f)
+	for i, p := range interp.points {
+		interp.panGradients[i] = gradient(p.X, p.Y)
+		interp.tiltGradients[i] = gradient(p.X, p.Y)
+	}
+
+	for _, q := range []delaunay.Point{{X: 0.1, Y: 0.1}, {X: 0.3, Y: 0.7}, {X: 0.52, Y: 0.48}, {X: 0.9, Y: 0.2}, {X: 0.7, Y: 0.95}} {
+		got, _, err := interp.Interpolate(q)
+		if err != nil {
+			t.Fatalf("%v: %s", q, err)
+		}
+		if want := f(q.X, q.Y); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%v = %v, want %v", q, got, want)
+		}
+	}
+}
+
+// The patches join with continuous first derivatives: the slope just either side of an interior
+// edge must agree.
+func TestCloughTocherContinuousSlopeAcrossEdges(t *testing.T) {
+	pan := func(x, y float64) float64 { return 30000 + 10000*math.Sin(3*x)*math.Cos(2*y) }
+	interp := newTestCloughTocher(t, calibrationGrid, pan, pan)
+
+	const h = 1e-6
+	for e, opposite := range interp.tri.Halfedges {
+		if opposite == -1 || opposite < e {
+			continue // hull edge, or the other half of an edge already checked
+		}
+		a := interp.points[interp.tri.Triangles[e]]
+		b := interp.points[interp.tri.Triangles[nextHalfedge(e)]]
+		mid := delaunay.Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+		// Step across the edge along its normal
+		nx, ny := -(b.Y - a.Y), b.X-a.X
+		length := math.Hypot(nx, ny)
+		nx, ny = nx/length, ny/length
+
+		value := func(d float64) float64 {
+			v, _, err := interp.Interpolate(delaunay.Point{X: mid.X + d*nx, Y: mid.Y + d*ny})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+		slopeBefore := (value(0) - value(-h)) / h
+		slopeAfter := (value(h) - value(0)) / h
+		if math.Abs(slopeBefore-slopeAfter) > 1e-2*math.Max(1, math.Abs(slopeBefore)) {
+			t.Errorf("slope across edge %v-%v jumps from %v to %v", a, b, slopeBefore, slopeAfter)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"gitlab.com/patopest/go-sacn"
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// sacnSourceConflictEvent is emitted to the frontend with a SourceConflict when another source starts
+// sending an output universe at the same priority.
+const sacnSourceConflictEvent = "sacnSourceConflict"
+
+// sourceConflictTimeout is how long after its last packet a conflicting source is no longer a conflict,
+// the same timeout receivers use to drop a source.
+const sourceConflictTimeout = sacn.NETWORK_DATA_LOSS_TIMEOUT * time.Millisecond
+
+// sourceConflict is another source sending one of our sACN output universes.
+type sourceConflict struct {
+	conflict SourceConflict
+	since    time.Time
+	lastSeen time.Time
+}
+
+// sacnOutputUniverses returns the active universes sent with sACN.
+func (a *App) sacnOutputUniverses() map[uint16]bool {
+	universes := make(map[uint16]bool)
+	for uni, protocol := range a.activeUniverses {
+		if protocol == ProtocolSACN {
+			universes[uni] = true
+		}
+	}
+	return universes
+}
+
+// checkSourceConflict notices another source sending levels on one of our sACN output universes at
+// our priority, receivers then merge the two sources or flicker between them.
+func (a *App) checkSourceConflict(p *packet.DataPacket, ip string, now time.Time) {
+	if p.GetStartCode() != startCodeNull || p.CID == a.sacnCID || a.activeUniverses[p.Universe] != ProtocolSACN {
+		return
+	}
+
+	priority := a.sacnConfig.universeConfig(p.Universe).priority()
+	conflicts := a.sourceConflicts[p.Universe]
+	if p.Priority != priority {
+		delete(conflicts, p.CID) // priority changed, one of the sources wins
+		return
+	}
+
+	if conflicts == nil {
+		conflicts = make(map[[16]byte]*sourceConflict)
+		a.sourceConflicts[p.Universe] = conflicts
+	}
+	conflict, exists := conflicts[p.CID]
+	if exists && now.Sub(conflict.lastSeen) < sourceConflictTimeout {
+		conflict.lastSeen = now
+		return
+	}
+
+	conflict = &sourceConflict{
+		conflict: SourceConflict{
+			Universe: p.Universe,
+			CID:      hex.EncodeToString(p.CID[:]),
+			Name:     p.GetSourceName(),
+			Ip:       ip,
+			Priority: p.Priority,
+		},
+		since:    now,
+		lastSeen: now,
+	}
+	conflicts[p.CID] = conflict
+	LogError("sACN source conflict on universe %d: %s (%s, CID %s) also sends at priority %d",
+		p.Universe, conflict.conflict.Name, ip, conflict.conflict.CID, p.Priority)
+	a.emit(sacnSourceConflictEvent, conflict.conflict)
+}
+
+// GetSourceConflicts returns the sources currently sending an output universe at the same priority.
+func (a *App) GetSourceConflicts() []SourceConflict {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	conflicts := make([]SourceConflict, 0)
+	for uni, sources := range a.sourceConflicts {
+		for cid, source := range sources {
+			if now.Sub(source.lastSeen) >= sourceConflictTimeout || a.activeUniverses[uni] != ProtocolSACN {
+				delete(sources, cid)
+				continue
+			}
+			conflict := source.conflict
+			conflict.DurationMs = now.Sub(source.since).Milliseconds()
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Universe != conflicts[j].Universe {
+			return conflicts[i].Universe < conflicts[j].Universe
+		}
+		return conflicts[i].Name < conflicts[j].Name
+	})
+	return conflicts
+}
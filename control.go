@@ -0,0 +1,160 @@
+package main
+
+// Fixture modes decide whether a fixture follows its tracking target while running.
+const (
+	FixtureModeFollow  = "follow"  // follow the tracking target, offset by the trim
+	FixtureModePark    = "park"    // hold the park position
+	FixtureModeRelease = "release" // stop driving the fixture, see releasesChannels for who drives it then
+)
+
+// releasesChannels returns whether a released fixture in the universe is left to the console or other
+// sources. That needs merging or per-address priority, otherwise Följe owns the universe and keeps
+// sending the fixture's last values, so it holds them.
+func (config SACNUniverseConfig) releasesChannels() bool {
+	return merging(config) || (config.PerAddressPriority && config.protocol() == ProtocolSACN)
+}
+
+func validFixtureMode(mode string) bool {
+	return mode == "" || mode == FixtureModeFollow || mode == FixtureModePark || mode == FixtureModeRelease
+}
+
+func (control FixtureControl) mode() string {
+	if control.Mode == "" {
+		return FixtureModeFollow
+	}
+	return control.Mode
+}
+
+func (control FixtureControl) equal(other FixtureControl) bool {
+	if (control.Park == nil) != (other.Park == nil) || (control.Park != nil && *control.Park != *other.Park) {
+		return false
+	}
+	return control.mode() == other.mode() && control.Trim == other.Trim
+}
+
+// following returns true if the fixture follows its tracking target, i.e. it is not parked,
+// released or held by a cue.
+func (a *App) following(fixtureId string) bool {
+	return a.fixtureControls[fixtureId].mode() == FixtureModeFollow && !a.heldByCue(fixtureId)
+}
+
+// parkPosition returns the position the fixture holds when parked.
+func (control FixtureControl) parkPosition(fixture Fixture) PanTilt {
+	if control.Park == nil {
+		return PanTilt{Pan: fixture.HomePan, Tilt: fixture.HomeTilt}
+	}
+	return *control.Park
+}
+
+// SetFixtureMode makes a fixture follow its target, hold its park position or be released.
+func (a *App) SetFixtureMode(fixtureId string, mode string) {
+	LogInfo("SetFixtureMode: %s %s", fixtureId, mode)
+	if !validFixtureMode(mode) {
+		LogError("Tried to set unknown mode %s for fixture %s", mode, fixtureId)
+		return
+	}
+	a.updateFixtureControl(fixtureId, func(control *FixtureControl) {
+		control.Mode = mode
+	})
+}
+
+// SetFixturePark sets the position a fixture holds when parked.
+func (a *App) SetFixturePark(fixtureId string, pan int, tilt int) {
+	LogInfo("SetFixturePark: %s pan %d, tilt %d", fixtureId, pan, tilt)
+	a.updateFixtureControl(fixtureId, func(control *FixtureControl) {
+		control.Park = &PanTilt{Pan: clampDMX16(pan), Tilt: clampDMX16(tilt)}
+	})
+}
+
+// SetFixtureTrim sets the offset added to the pan and tilt a fixture follows, to nudge it live.
+func (a *App) SetFixtureTrim(fixtureId string, pan int, tilt int) {
+	a.updateFixtureControl(fixtureId, func(control *FixtureControl) {
+		control.Trim = PanTilt{Pan: min(max(pan, -maxPanTiltValue), maxPanTiltValue), Tilt: min(max(tilt, -maxPanTiltValue), maxPanTiltValue)}
+	})
+}
+
+func (a *App) updateFixtureControl(fixtureId string, update func(control *FixtureControl)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.fixtures[fixtureId]; !exists {
+		LogError("Tried to set control for non-existing fixture: %s", fixtureId)
+		return
+	}
+	control := a.fixtureControls[fixtureId]
+	update(&control)
+	a.fixtureControls[fixtureId] = control
+	a.applyFixtureControl(fixtureId)
+}
+
+// SetFixtureControls replaces the controls of all fixtures, when loading a show file. Fixtures
+// left out follow without trim.
+func (a *App) SetFixtureControls(controls map[string]FixtureControl) {
+	LogInfo("SetFixtureControls: %d fixture(s)", len(controls))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	previous := a.fixtureControls
+	a.fixtureControls = make(map[string]FixtureControl, len(controls))
+	for id, control := range controls {
+		if !validFixtureMode(control.Mode) {
+			LogError("Unknown mode %s for fixture %s, following instead", control.Mode, id)
+			control.Mode = FixtureModeFollow
+		}
+		a.fixtureControls[id] = control
+	}
+
+	for id := range a.fixtures {
+		if !previous[id].equal(a.fixtureControls[id]) {
+			a.applyFixtureControl(id)
+		}
+	}
+}
+
+// GetFixtureControls returns the control of every fixture.
+func (a *App) GetFixtureControls() map[string]FixtureControl {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	controls := make(map[string]FixtureControl, len(a.fixtures))
+	for id := range a.fixtures {
+		control := a.fixtureControls[id]
+		control.Mode = control.mode()
+		controls[id] = control
+	}
+	return controls
+}
+
+// applyFixtureControl moves a fixture as its control says, after the control changed.
+func (a *App) applyFixtureControl(fixtureId string) {
+	fixture, exists := a.fixtures[fixtureId]
+	if !exists {
+		return
+	}
+
+	control := a.fixtureControls[fixtureId]
+	switch control.mode() {
+	case FixtureModePark:
+		park := control.parkPosition(fixture)
+		a.setPanTiltTarget(fixtureId, park.Pan, park.Tilt)
+	case FixtureModeRelease:
+		delete(a.motionFilters, fixtureId) // stop where it is
+		if !a.sacnConfig.universeConfig(fixture.Universe).releasesChannels() {
+			LogInfo("Released fixture %s holds its last values, universe %d neither merges nor sends per-address priority", fixture.Name, fixture.Universe)
+		}
+	default:
+		if position, exists := a.targetPositions[fixture.target()]; exists {
+			a.setTargetPosition(fixture.target(), position.X, position.Y)
+		}
+	}
+	a.dmxChanged()
+}
+
+// applyParkedFixtures moves the parked fixtures back to their park position, after the DMX data was reset.
+func (a *App) applyParkedFixtures() {
+	for id := range a.fixtures {
+		if a.fixtureControls[id].mode() == FixtureModePark {
+			a.applyFixtureControl(id)
+		}
+	}
+}
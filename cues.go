@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Easings shape how a cue fades over its fade time.
+const (
+	EasingLinear = "linear"
+	EasingIn     = "easeIn"    // start slowly
+	EasingOut    = "easeOut"   // stop slowly
+	EasingInOut  = "easeInOut" // start and stop slowly
+)
+
+// runningCue is a cue fading the fixtures to its preset, or holding them there once the fade is done.
+type runningCue struct {
+	cue   Cue
+	start time.Time
+	fades map[string]*fixtureFade // by fixture id
+}
+
+// fixtureFade is how a fixture moves during a cue: between two positions on the video, so the beam
+// travels in a straight line on stage, or else between two pan/tilt values.
+type fixtureFade struct {
+	onVideo                bool
+	fromPoint, toPoint     Point
+	point                  Point // where the fixture points on the video now
+	fromPanTilt, toPanTilt PanTilt
+}
+
+func validEasing(easing string) bool {
+	return easing == "" || easing == EasingLinear || easing == EasingIn || easing == EasingOut || easing == EasingInOut
+}
+
+// ease maps how far through the fade time a cue is to how far it has faded, both from 0 to 1.
+func ease(easing string, t float64) float64 {
+	switch easing {
+	case EasingIn:
+		return t * t
+	case EasingOut:
+		return 1 - (1-t)*(1-t)
+	case EasingInOut:
+		return t * t * (3 - 2*t)
+	default:
+		return t
+	}
+}
+
+func lerp(from, to, t float64) float64 {
+	return from + (to-from)*t
+}
+
+// progress returns how far through its fade time the cue is at now, from 0 to 1.
+func (cue *runningCue) progress(now time.Time) float64 {
+	if cue.cue.FadeTime <= 0 {
+		return 1
+	}
+	return min(now.Sub(cue.start).Seconds()/cue.cue.FadeTime, 1)
+}
+
+func (a *App) heldByCue(fixtureId string) bool {
+	if a.cue == nil {
+		return false
+	}
+	_, held := a.cue.fades[fixtureId]
+	return held
+}
+
+func (a *App) SetPresets(presets []Preset) {
+	LogInfo("SetPresets: %d preset(s)", len(presets))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.presets = presets
+}
+
+func (a *App) SetCues(cues []Cue) {
+	LogInfo("SetCues: %d cue(s)", len(cues))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cues = cues
+}
+
+// GoCue fades the following fixtures to the preset of a cue, by id or name, and holds them there
+// until ReleaseCue or the next cue.
+func (a *App) GoCue(idOrName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.goCue(idOrName, time.Now()); err != nil {
+		LogError("Failed to go to cue %s: %s", idOrName, err.Error())
+	}
+}
+
+func (a *App) goCue(idOrName string, now time.Time) error {
+	cue, exists := a.findCue(idOrName)
+	if !exists {
+		return fmt.Errorf("no cue with id or name %s", idOrName)
+	}
+	if !validEasing(cue.Easing) {
+		return fmt.Errorf("unknown easing %s", cue.Easing)
+	}
+	var preset *Preset
+	for i := range a.presets {
+		if a.presets[i].Id == cue.PresetId {
+			preset = &a.presets[i]
+			break
+		}
+	}
+	if preset == nil {
+		return fmt.Errorf("cue %s has no preset", cue.Name)
+	}
+
+	LogInfo("Go cue %s to preset %s in %.1f s", cue.Name, preset.Name, cue.FadeTime)
+	fades := make(map[string]*fixtureFade)
+	for _, fixture := range a.fixtures {
+		if a.fixtureControls[fixture.Id].mode() != FixtureModeFollow {
+			continue
+		}
+		if fade, moves := a.fadeTo(fixture, *preset); moves {
+			fades[fixture.Id] = fade
+		}
+	}
+	a.cue = &runningCue{cue: cue, start: now, fades: fades}
+	a.dmxChanged()
+	return nil
+}
+
+// findCue returns the cue with the given id, or else the first cue with the given name.
+func (a *App) findCue(idOrName string) (Cue, bool) {
+	for _, cue := range a.cues {
+		if cue.Id == idOrName {
+			return cue, true
+		}
+	}
+	for _, cue := range a.cues {
+		if cue.Name == idOrName {
+			return cue, true
+		}
+	}
+	return Cue{}, false
+}
+
+// fadeTo returns how a fixture fades from where it points to a preset, false if the preset does not move it.
+func (a *App) fadeTo(fixture Fixture, preset Preset) (*fixtureFade, bool) {
+	from, sent := a.lastPanTilt[fixture.Id]
+	if preset.Position == nil {
+		to, exists := preset.PanTilt[fixture.Id]
+		if !exists {
+			return nil, false
+		}
+		if !sent {
+			from = to
+		}
+		return &fixtureFade{fromPanTilt: from, toPanTilt: to}, true
+	}
+
+	to := *preset.Position
+	if point, known := a.pointedAt(fixture); known {
+		return &fixtureFade{onVideo: true, fromPoint: point, toPoint: to, point: point}, true
+	}
+
+	// Where the fixture points on the video is not known, e.g. a pan/tilt preset left it there
+	toPanTilt, calibrated := a.panTiltAt(fixture, a.layerAt(to), to)
+	if !calibrated {
+		return nil, false
+	}
+	if !sent {
+		from = toPanTilt
+	}
+	return &fixtureFade{fromPanTilt: from, toPanTilt: toPanTilt}, true
+}
+
+// pointedAt returns the position on the video a following fixture points at, if known.
+func (a *App) pointedAt(fixture Fixture) (Point, bool) {
+	if a.cue != nil {
+		if fade, held := a.cue.fades[fixture.Id]; held {
+			return fade.point, fade.onVideo
+		}
+	}
+	point, exists := a.targetPositions[fixture.target()]
+	return point, exists
+}
+
+// stepCue moves the fixtures held by the cue to where the fade is at now. Called by the sACN worker,
+// returns true while fading.
+func (a *App) stepCue(now time.Time) bool {
+	if a.cue == nil {
+		return false
+	}
+
+	progress := a.cue.progress(now)
+	t := ease(a.cue.cue.Easing, progress)
+	for fixtureId, fade := range a.cue.fades {
+		fixture, exists := a.fixtures[fixtureId]
+		if !exists || a.fixtureControls[fixtureId].mode() != FixtureModeFollow {
+			delete(a.cue.fades, fixtureId) // parked or released during the cue
+			continue
+		}
+
+		if fade.onVideo {
+			fade.point = Point{X: lerp(fade.fromPoint.X, fade.toPoint.X, t), Y: lerp(fade.fromPoint.Y, fade.toPoint.Y, t)}
+			a.pointFixtureAt(fixture, fade.point)
+		} else {
+			// Fixtures wrapping their pan fade the shortest way round, as pointFixtureAt moves them
+			fromPan, toPan := float64(fade.fromPanTilt.Pan), float64(fade.toPanTilt.Pan)
+			if turn, wraps := panTurn(fixture); wraps {
+				toPan = wrapPan(toPan, fromPan, turn)
+			}
+			pan := lerp(fromPan, toPan, t)
+			tilt := lerp(float64(fade.fromPanTilt.Tilt), float64(fade.toPanTilt.Tilt), t)
+			a.setPanTiltTarget(fixtureId, int(math.Round(pan)), int(math.Round(tilt)))
+		}
+	}
+	return progress < 1
+}
+
+// ReleaseCue lets the fixtures held by a cue follow their tracking target again.
+func (a *App) ReleaseCue() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cue == nil {
+		return
+	}
+	LogInfo("Releasing cue %s", a.cue.cue.Name)
+	a.cue = nil
+	for target, position := range a.targetPositions {
+		a.setTargetPosition(target, position.X, position.Y)
+	}
+}
+
+// GetActiveCue returns the cue holding the fixtures and how far it has faded.
+func (a *App) GetActiveCue() ActiveCue {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cue == nil {
+		return ActiveCue{}
+	}
+	return ActiveCue{CueId: a.cue.cue.Id, Progress: a.cue.progress(time.Now())}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// newCueTestApp returns an app with a following, a parked and a released fixture, and a preset moving
+// each of them by pan/tilt.
+func newCueTestApp() *App {
+	park := PanTilt{Pan: 5000, Tilt: 5000}
+	return &App{
+		fixtures: map[string]Fixture{
+			"spot":     {Id: "spot", Name: "Spot", Universe: 1, PanAddress: 0, FinePanAddress: 1, TiltAddress: 2, FineTiltAddress: 3},
+			"parked":   {Id: "parked", Name: "Parked", Universe: 1, PanAddress: 10, FinePanAddress: 11, TiltAddress: 12, FineTiltAddress: 13},
+			"released": {Id: "released", Name: "Released", Universe: 1, PanAddress: 20, FinePanAddress: 21, TiltAddress: 22, FineTiltAddress: 23},
+		},
+		fixtureControls: map[string]FixtureControl{
+			"parked":   {Mode: FixtureModePark, Park: &park},
+			"released": {Mode: FixtureModeRelease},
+		},
+		presets: []Preset{
+			{Id: "p1", Name: "Up", PanTilt: map[string]PanTilt{
+				"spot": {Pan: 16000, Tilt: 32000}, "parked": {Pan: 16000, Tilt: 32000}, "released": {Pan: 16000, Tilt: 32000},
+			}},
+			{Id: "p2", Name: "Centre", Position: &Point{X: 0.8, Y: 0.8}},
+			{Id: "p3", Name: "Side", Position: &Point{X: 0.8, Y: 0.2}},
+		},
+		lastPanTilt:     map[string]PanTilt{"spot": {}, "parked": park, "released": {Pan: 1000, Tilt: 1000}},
+		universeDMXData: make(map[uint16]DMXData),
+		motionFilters:   make(map[string]*motionFilterState),
+		targetPositions: make(map[string]Point),
+	}
+}
+
+func TestEase(t *testing.T) {
+	tests := []struct {
+		easing string
+		t      float64
+		want   float64
+	}{
+		{"", 0.25, 0.25},
+		{EasingLinear, 0.25, 0.25},
+		{EasingIn, 0.25, 0.0625},
+		{EasingOut, 0.25, 0.4375},
+		{EasingInOut, 0.25, 0.15625},
+		{EasingInOut, 0.5, 0.5},
+		{EasingInOut, 0.75, 0.84375},
+	}
+	for _, test := range tests {
+		if got := ease(test.easing, test.t); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("ease(%q, %v) = %v, want %v", test.easing, test.t, got, test.want)
+		}
+		for _, end := range []float64{0, 1} {
+			if got := ease(test.easing, end); got != end {
+				t.Errorf("ease(%q, %v) = %v, want %v", test.easing, end, got, end)
+			}
+		}
+	}
+}
+
+func TestStepCueEases(t *testing.T) {
+	tests := []struct {
+		easing    string
+		at        time.Duration
+		pan, tilt int
+		fading    bool
+	}{
+		{EasingLinear, 500 * time.Millisecond, 4000, 8000, true},
+		{EasingIn, 500 * time.Millisecond, 1000, 2000, true},
+		{EasingOut, 500 * time.Millisecond, 7000, 14000, true},
+		{EasingInOut, time.Second, 8000, 16000, true},
+		{EasingIn, 2 * time.Second, 16000, 32000, false},
+		{EasingIn, 3 * time.Second, 16000, 32000, false},
+	}
+	for _, test := range tests {
+		a := newCueTestApp()
+		a.cues = []Cue{{Id: "c1", Name: "Up", PresetId: "p1", FadeTime: 2, Easing: test.easing}}
+		start := time.Unix(100, 0)
+		if err := a.goCue("Up", start); err != nil {
+			t.Fatal(err)
+		}
+
+		fading := a.stepCue(start.Add(test.at))
+		if got := a.lastPanTilt["spot"]; got != (PanTilt{Pan: test.pan, Tilt: test.tilt}) || fading != test.fading {
+			t.Errorf("%s at %s: %+v, fading %v, want %d/%d, fading %v", test.easing, test.at, got, fading, test.pan, test.tilt, test.fading)
+		}
+	}
+}
+
+func TestGoCueSkipsParkedAndReleasedFixtures(t *testing.T) {
+	a := newCueTestApp()
+	a.cues = []Cue{{Id: "c1", Name: "Up", PresetId: "p1", FadeTime: 1}}
+	start := time.Unix(100, 0)
+	if err := a.goCue("c1", start); err != nil {
+		t.Fatal(err)
+	}
+	if _, held := a.cue.fades["spot"]; !held || len(a.cue.fades) != 1 {
+		t.Fatalf("cue holds %v, want only spot", a.cue.fades)
+	}
+
+	a.stepCue(start.Add(time.Second))
+	if got := a.lastPanTilt["parked"]; got != (PanTilt{Pan: 5000, Tilt: 5000}) {
+		t.Errorf("parked fixture moved to %+v", got)
+	}
+	if got := a.lastPanTilt["released"]; got != (PanTilt{Pan: 1000, Tilt: 1000}) {
+		t.Errorf("released fixture moved to %+v", got)
+	}
+}
+
+func TestStepCueDropsFixturesReleasedDuringTheCue(t *testing.T) {
+	a := newCueTestApp()
+	a.cues = []Cue{{Id: "c1", Name: "Up", PresetId: "p1", FadeTime: 2}}
+	start := time.Unix(100, 0)
+	if err := a.goCue("c1", start); err != nil {
+		t.Fatal(err)
+	}
+	a.stepCue(start.Add(500 * time.Millisecond))
+	halfway := a.lastPanTilt["spot"]
+
+	a.fixtureControls["spot"] = FixtureControl{Mode: FixtureModeRelease}
+	a.stepCue(start.Add(time.Second))
+	if _, held := a.cue.fades["spot"]; held {
+		t.Error("cue still holds the released fixture")
+	}
+	if got := a.lastPanTilt["spot"]; got != halfway {
+		t.Errorf("released fixture moved from %+v to %+v", halfway, got)
+	}
+}
+
+func TestGoCueTakesOverFromRunningCue(t *testing.T) {
+	a := newCueTestApp()
+	a.cues = []Cue{
+		{Id: "c1", Name: "Centre", PresetId: "p2", FadeTime: 2},
+		{Id: "c2", Name: "Side", PresetId: "p3", FadeTime: 2},
+	}
+	a.targetPositions[DefaultTarget] = Point{X: 0.2, Y: 0.2}
+	start := time.Unix(100, 0)
+
+	if err := a.goCue("Centre", start); err != nil {
+		t.Fatal(err)
+	}
+	if fade := a.cue.fades["spot"]; fade == nil || !fade.onVideo || fade.fromPoint != (Point{X: 0.2, Y: 0.2}) {
+		t.Fatalf("first cue fades %+v, want from the tracking position", fade)
+	}
+	a.stepCue(start.Add(time.Second))
+
+	// The next cue starts where the running one is, not at the tracking position
+	if err := a.goCue("Side", start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	fade := a.cue.fades["spot"]
+	if fade == nil || fade.fromPoint != (Point{X: 0.5, Y: 0.5}) || fade.toPoint != (Point{X: 0.8, Y: 0.2}) {
+		t.Fatalf("second cue fades %+v, want from 0.5, 0.5 to 0.8, 0.2", fade)
+	}
+	if got := a.cue.cue.Id; got != "c2" {
+		t.Errorf("running cue %s, want c2", got)
+	}
+	if _, held := a.cue.fades["parked"]; held {
+		t.Error("second cue holds the parked fixture")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/hex"
+	"slices"
+	"sort"
+	"time"
+
+	"gitlab.com/patopest/go-sacn"
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// discoveredSourceTimeout is how long a source is listed after its last universe discovery packet,
+// sources send one every 10 seconds.
+const discoveredSourceTimeout = 3 * sacn.UNIVERSE_DISCOVERY_INTERVAL * time.Second
+
+// discoveredSource is a source found by E1.31 universe discovery (section 8). Sources sending more
+// than 512 universes list them over several pages.
+type discoveredSource struct {
+	name  string
+	ip    string
+	pages map[uint8][]uint16
+	last  uint8
+	seen  time.Time
+}
+
+// receiveSACNDiscovery stores the universes a source advertises. Called by the receiver.
+//
+// Följe advertises its own sACN universes the same way, see sacnOutput.discoveryLoop.
+func (a *App) receiveSACNDiscovery(p packet.SACNPacket, ip string) {
+	discovery, ok := p.(*packet.DiscoveryPacket)
+	if !ok {
+		return
+	}
+	universes, valid := discoveredUniverses(discovery)
+	if !valid {
+		LogDebug("Ignoring malformed sACN universe discovery packet from %s", ip)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	source, exists := a.discoveredSources[discovery.CID]
+	if !exists || source.last != discovery.Last {
+		if !exists {
+			LogInfo("Discovered sACN source %s (%s)", discovery.GetSourceName(), ip)
+		}
+		source = &discoveredSource{pages: make(map[uint8][]uint16)}
+		a.discoveredSources[discovery.CID] = source
+	}
+
+	source.name = discovery.GetSourceName()
+	source.ip = ip
+	source.last = discovery.Last
+	source.seen = time.Now()
+	source.pages[discovery.Page] = universes
+}
+
+// discoveredUniverses returns the universes listed by a discovery packet, false if its universe
+// discovery layer is shorter than its header. The packet's GetNumUniverses trusts the layer length,
+// so the count is clamped to the universes the packet has room for and the framing layer says it
+// carries, the framing layer starting 38 bytes into the packet and the list 120 bytes in.
+func discoveredUniverses(d *packet.DiscoveryPacket) ([]uint16, bool) {
+	if d.UDLLength&0x0FFF < 8 {
+		return nil, false
+	}
+	n := min(d.GetNumUniverses(), len(d.Universes), max(int(d.FrameLength&0x0FFF)+38-120, 0)/2)
+	return slices.Clone(d.Universes[:n]), true
+}
+
+// GetDiscoveredSources returns the sACN sources on the network and the universes they send, found by
+// E1.31 universe discovery.
+func (a *App) GetDiscoveredSources() []DiscoveredSource {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	sources := make([]DiscoveredSource, 0, len(a.discoveredSources))
+	for cid, source := range a.discoveredSources {
+		if time.Since(source.seen) > discoveredSourceTimeout {
+			delete(a.discoveredSources, cid)
+			continue
+		}
+
+		universes := make([]uint16, 0)
+		for page, pageUniverses := range source.pages {
+			if page <= source.last {
+				universes = append(universes, pageUniverses...)
+			}
+		}
+		slices.Sort(universes)
+
+		sources = append(sources, DiscoveredSource{
+			CID:       hex.EncodeToString(cid[:]),
+			Name:      source.name,
+			Ip:        source.ip,
+			Own:       cid == a.sacnCID,
+			Universes: slices.Compact(universes),
+		})
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].Ip != sources[j].Ip {
+			return sources[i].Ip < sources[j].Ip
+		}
+		return sources[i].Name < sources[j].Name
+	})
+	return sources
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/binary"
+	"slices"
+	"testing"
+
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// discoveryPacketBytes returns a universe discovery packet listing universes, with its universe
+// discovery layer length replaced by udlLength unless it is zero.
+func discoveryPacketBytes(t *testing.T, universes []uint16, udlLength uint16) []byte {
+	t.Helper()
+	p := packet.NewDiscoveryPacket()
+	p.CID = [16]byte{0xD1, 0x5C}
+	p.SetSourceName("Console")
+	if err := p.SetUniverses(universes); err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if udlLength != 0 {
+		binary.BigEndian.PutUint16(b[112:114], udlLength)
+	}
+	return b
+}
+
+func TestReceiveSACNDiscovery(t *testing.T) {
+	tests := []struct {
+		name      string
+		udlLength uint16
+		universes []uint16 // nil if the packet is ignored
+	}{
+		{"valid", 0, []uint16{1, 2, 7}},
+		{"layer shorter than its header", 0x7004, nil},
+		{"empty layer", 0x7008, []uint16{}},
+		{"layer longer than the packet", 0x7FFF, []uint16{1, 2, 7}},
+		{"layer one universe short", 0x700C, []uint16{1, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := packet.Unmarshal(discoveryPacketBytes(t, []uint16{1, 2, 7}, test.udlLength))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := &App{discoveredSources: make(map[[16]byte]*discoveredSource)}
+			a.receiveSACNDiscovery(p, "10.0.0.2")
+
+			sources := a.GetDiscoveredSources()
+			if test.universes == nil {
+				if len(sources) != 0 {
+					t.Errorf("stored %v from a malformed packet", sources)
+				}
+				return
+			}
+			if len(sources) != 1 {
+				t.Fatalf("got %d sources, want 1", len(sources))
+			}
+			if !slices.Equal(sources[0].Universes, test.universes) {
+				t.Errorf("universes %v, want %v", sources[0].Universes, test.universes)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Motion filter modes smooth the pan/tilt of a fixture on the sACN tick before it is written to DMX.
+const (
+	MotionFilterNone        = "none"        // write the interpolated position straight away
+	MotionFilterExponential = "exponential" // move a fixed fraction of the remaining distance per SmoothTime
+	MotionFilterSpring      = "spring"      // critically damped spring, settles in about SmoothTime without overshoot
+	MotionFilterOneEuro     = "one-euro"    // 1€ filter, heavy smoothing when slow and little lag when fast
+)
+
+// maxFilterStep caps the time step of the filters so a stalled tick does not make the fixtures jump.
+const maxFilterStep = 250 * time.Millisecond
+
+// oneEuroDerivativeCutoff is the cutoff frequency (Hz) used to smooth the speed estimate of the 1€ filter.
+const oneEuroDerivativeCutoff = 1.0
+
+// axisFilter is the state of the motion filter for one of pan or tilt.
+type axisFilter struct {
+	value    float64 // current output
+	velocity float64 // output units per second
+	speed    float64 // smoothed speed of the target, used by the 1€ filter
+}
+
+// motionFilterState is the state of the motion filter of a fixture.
+type motionFilterState struct {
+	target PanTilt // latest interpolated position
+	pan    axisFilter
+	tilt   axisFilter
+}
+
+func newMotionFilterState(panTilt PanTilt) *motionFilterState {
+	return &motionFilterState{
+		target: panTilt,
+		pan:    axisFilter{value: float64(panTilt.Pan)},
+		tilt:   axisFilter{value: float64(panTilt.Tilt)},
+	}
+}
+
+func motionFilterEnabled(filter MotionFilter) bool {
+	switch filter.Mode {
+	case MotionFilterExponential, MotionFilterSpring, MotionFilterOneEuro:
+		return true
+	default:
+		return filter.MaxVelocity > 0 || filter.MaxAcceleration > 0
+	}
+}
+
+// step advances the filter towards target by dt seconds and returns the new output.
+func (axis *axisFilter) step(filter MotionFilter, target float64, dt float64) float64 {
+	if dt <= 0 {
+		return axis.value
+	}
+
+	previous := axis.velocity
+	next := target
+	switch filter.Mode {
+	case MotionFilterExponential:
+		if filter.SmoothTime > 0 {
+			next = axis.value + (target-axis.value)*(1-math.Exp(-dt/filter.SmoothTime))
+		}
+	case MotionFilterSpring:
+		if filter.SmoothTime > 0 {
+			next = axis.stepSpring(filter.SmoothTime, target, dt)
+		}
+	case MotionFilterOneEuro:
+		next = axis.stepOneEuro(filter, target, dt)
+	}
+
+	// Limit speed and acceleration in DMX units, the limits are given as full range per second
+	velocity := (next - axis.value) / dt
+	limited := velocity
+	if filter.MaxAcceleration > 0 {
+		acceleration := filter.MaxAcceleration * maxPanTiltValue
+		maxChange := acceleration * dt
+		limited = previous + math.Max(-maxChange, math.Min(maxChange, limited-previous))
+
+		// Brake in time to stop at the target instead of overshooting it
+		distance := target - axis.value
+		brake := math.Min(math.Sqrt(2*acceleration*math.Abs(distance)), math.Abs(distance)/dt)
+		if limited*distance >= 0 && math.Abs(limited) > brake {
+			limited = math.Copysign(brake, distance)
+		}
+	}
+	if filter.MaxVelocity > 0 {
+		maxVelocity := filter.MaxVelocity * maxPanTiltValue
+		limited = math.Max(-maxVelocity, math.Min(maxVelocity, limited))
+	}
+
+	if limited != velocity || filter.Mode != MotionFilterSpring {
+		axis.velocity = limited // the spring keeps its own velocity unless it was limited
+	}
+	axis.value = clampPanTiltValue(axis.value + limited*dt)
+	return axis.value
+}
+
+// stepSpring moves along a critically damped spring, using the approximation of exp from
+// "Critically Damped Ease-In/Ease-Out Smoothing" in Game Programming Gems 4.
+func (axis *axisFilter) stepSpring(smoothTime float64, target float64, dt float64) float64 {
+	omega := 2 / smoothTime
+	x := omega * dt
+	decay := 1 / (1 + x + 0.48*x*x + 0.235*x*x*x)
+	change := axis.value - target
+	temp := (axis.velocity + omega*change) * dt
+	axis.velocity = (axis.velocity - omega*temp) * decay
+	return target + (change+temp)*decay
+}
+
+// stepOneEuro applies the 1€ filter (Casiez et al. 2012): a low pass filter whose cutoff rises with
+// the speed of the signal, so jitter is removed when still without lagging behind fast moves.
+func (axis *axisFilter) stepOneEuro(filter MotionFilter, target float64, dt float64) float64 {
+	minCutoff := filter.MinCutoff
+	if minCutoff <= 0 {
+		minCutoff = 1
+	}
+
+	axis.speed += lowPassAlpha(oneEuroDerivativeCutoff, dt) * ((target-axis.value)/dt - axis.speed)
+	cutoff := minCutoff + filter.Beta*math.Abs(axis.speed)/maxPanTiltValue
+	return axis.value + lowPassAlpha(cutoff, dt)*(target-axis.value)
+}
+
+// lowPassAlpha returns the smoothing factor of a first order low pass filter with the given cutoff (Hz).
+func lowPassAlpha(cutoff float64, dt float64) float64 {
+	tau := 1 / (2 * math.Pi * cutoff)
+	return 1 / (1 + tau/dt)
+}
+
+// setPanTiltTarget sets where a fixture should point. Fixtures without a motion filter are written
+// to DMX straight away, the others move there on the sACN tick, see stepMotionFilters.
+func (a *App) setPanTiltTarget(fixtureId string, pan int, tilt int) {
+	fixture, exists := a.fixtures[fixtureId]
+	if !exists || !motionFilterEnabled(fixture.MotionFilter) {
+		delete(a.motionFilters, fixtureId)
+		a.setPanTiltForFixture(fixtureId, pan, tilt)
+		return
+	}
+
+	state, exists := a.motionFilters[fixtureId]
+	if !exists {
+		// Start from where the fixture was last sent, or snap to the target if it has not been sent yet
+		start, sent := a.lastPanTilt[fixtureId]
+		if !sent {
+			start = PanTilt{Pan: pan, Tilt: tilt}
+		}
+		state = newMotionFilterState(start)
+		a.motionFilters[fixtureId] = state
+	}
+	state.target = PanTilt{Pan: pan, Tilt: tilt}
+}
+
+// stepMotionFilters advances the motion filter of every fixture by dt and writes the result to DMX.
+// Returns true while any fixture is still moving towards its target.
+func (a *App) stepMotionFilters(dt time.Duration) bool {
+	moving := false
+	seconds := min(dt, maxFilterStep).Seconds()
+	for fixtureId, state := range a.motionFilters {
+		fixture, exists := a.fixtures[fixtureId]
+		if !exists || !motionFilterEnabled(fixture.MotionFilter) {
+			delete(a.motionFilters, fixtureId)
+			continue
+		}
+
+		pan := state.pan.step(fixture.MotionFilter, float64(state.target.Pan), seconds)
+		tilt := state.tilt.step(fixture.MotionFilter, float64(state.target.Tilt), seconds)
+		a.setPanTiltForFixture(fixtureId, int(math.Round(pan)), int(math.Round(tilt)))
+		moving = moving || !state.pan.settled(float64(state.target.Pan)) || !state.tilt.settled(float64(state.target.Tilt))
+	}
+	return moving
+}
+
+// settled returns true if the axis has stopped at target, within what can be sent as DMX.
+func (axis *axisFilter) settled(target float64) bool {
+	return math.Abs(target-axis.value) < 0.5 && math.Abs(axis.velocity) < 0.5
+}
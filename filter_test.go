@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+const filterTick = 0.04 // seconds, the sACN tick at 25 fps
+
+// stepResponse runs an axis resting at 0 towards a step to target, returning the output after every tick.
+func stepResponse(filter MotionFilter, target float64, seconds float64) []float64 {
+	axis := axisFilter{}
+	values := make([]float64, int(math.Round(seconds/filterTick)))
+	for i := range values {
+		values[i] = axis.step(filter, target, filterTick)
+	}
+	return values
+}
+
+// valueAt returns the output at t seconds after the step.
+func valueAt(values []float64, t float64) float64 {
+	return values[int(math.Round(t/filterTick))-1]
+}
+
+func TestMotionFilterStepResponse(t *testing.T) {
+	const target = 30000
+	tests := []struct {
+		name   string
+		filter MotionFilter
+		check  func(t *testing.T, values []float64)
+	}{
+		{
+			name:   "none",
+			filter: MotionFilter{Mode: MotionFilterNone},
+			check: func(t *testing.T, values []float64) {
+				if values[0] != target {
+					t.Errorf("first tick %v, want %v", values[0], target)
+				}
+			},
+		},
+		{
+			name:   "exponential",
+			filter: MotionFilter{Mode: MotionFilterExponential, SmoothTime: 0.5},
+			check: func(t *testing.T, values []float64) {
+				for _, at := range []float64{0.04, 0.48, 1} {
+					want := target * (1 - math.Exp(-at/0.5))
+					if got := valueAt(values, at); math.Abs(got-want) > 1e-6 {
+						t.Errorf("at %v s %v, want %v", at, got, want)
+					}
+				}
+			},
+		},
+		{
+			name:   "spring",
+			filter: MotionFilter{Mode: MotionFilterSpring, SmoothTime: 0.5},
+			check: func(t *testing.T, values []float64) {
+				// A critically damped spring with omega 2/SmoothTime is at 1-(1+ωt)e^-ωt of the step,
+				// the approximated exp is within a percent of it
+				for _, at := range []float64{0.2, 0.48, 1} {
+					x := 2 / 0.5 * at
+					want := target * (1 - (1+x)*math.Exp(-x))
+					if got := valueAt(values, at); math.Abs(got-want) > 0.01*target {
+						t.Errorf("at %v s %v, want about %v", at, got, want)
+					}
+				}
+				if got := valueAt(values, 4); math.Abs(got-target) > 0.5 {
+					t.Errorf("at 4 s %v, want settled at %v", got, target)
+				}
+			},
+		},
+		{
+			name:   "one-euro",
+			filter: MotionFilter{Mode: MotionFilterOneEuro, MinCutoff: 1, Beta: 10},
+			check: func(t *testing.T, values []float64) {
+				// Fast moves raise the cutoff, so it lags less than the 1 Hz low pass it starts from
+				lowPass := target * (1 - math.Pow(1-lowPassAlpha(1, filterTick), 5))
+				if got := valueAt(values, 0.2); got <= lowPass {
+					t.Errorf("at 0.2 s %v, want ahead of the low pass at %v", got, lowPass)
+				}
+				if got := valueAt(values, 3); math.Abs(got-target) > 0.5 {
+					t.Errorf("at 3 s %v, want settled at %v", got, target)
+				}
+			},
+		},
+		{
+			name:   "max velocity",
+			filter: MotionFilter{Mode: MotionFilterNone, MaxVelocity: 0.5},
+			check: func(t *testing.T, values []float64) {
+				if got, want := valueAt(values, 0.4), 0.4*0.5*maxPanTiltValue; math.Abs(got-want) > 1e-6 {
+					t.Errorf("at 0.4 s %v, want %v", got, want)
+				}
+				if got := valueAt(values, 1); got != target {
+					t.Errorf("at 1 s %v, want arrived at %v", got, target)
+				}
+			},
+		},
+		{
+			name:   "max acceleration",
+			filter: MotionFilter{Mode: MotionFilterNone, MaxAcceleration: 1},
+			check: func(t *testing.T, values []float64) {
+				// Speeding up by the limit every tick
+				n := 10.0
+				want := maxPanTiltValue * filterTick * filterTick * n * (n + 1) / 2
+				if got := valueAt(values, n*filterTick); math.Abs(got-want) > 1e-6 {
+					t.Errorf("after %v ticks %v, want %v", n, got, want)
+				}
+				if got := valueAt(values, 2); got != target {
+					t.Errorf("at 2 s %v, want stopped at %v", got, target)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := stepResponse(test.filter, target, 5)
+			previous := 0.0
+			for i, value := range values {
+				// None of the filters may overshoot or move back, the fixture would visibly bounce
+				if value < previous || value > target {
+					t.Fatalf("tick %d: %v after %v, overshoots or moves back", i, value, previous)
+				}
+				previous = value
+			}
+			test.check(t, values)
+		})
+	}
+}
+
+func TestMotionFilterSkipsZeroStep(t *testing.T) {
+	axis := axisFilter{value: 100, velocity: 50}
+	if got := axis.step(MotionFilter{Mode: MotionFilterSpring, SmoothTime: 0.5}, 1000, 0); got != 100 || axis.velocity != 50 {
+		t.Errorf("zero step moved to %v with velocity %v", got, axis.velocity)
+	}
+}
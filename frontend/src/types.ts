@@ -1,3 +1,5 @@
+import type { main } from "../wailsjs/go/models";
+
 export interface Fixture {
     id: string;
     name: string;
@@ -6,24 +8,75 @@ export interface Fixture {
     finePanAddress: number;
     tiltAddress: number;
     fineTiltAddress: number;
+    profileId?: string;
+    profileMode?: string;
+    startAddress?: number;
     minPan: number;
     maxPan: number;
     minTilt: number;
     maxTilt: number;
+    panRangeDegrees?: number;
+    panWrap?: string;
+    homePan?: number;
+    homeTilt?: number;
+    target?: string;
+    edgeMode: string;
+    interpolation: string;
+    knownPose?: main.FixturePose;
+    filterMode?: string;
+    filterSmoothTime?: number;
+    filterMinCutoff?: number;
+    filterBeta?: number;
+    filterMaxVelocity?: number;
+    filterMaxAcceleration?: number;
+    channels?: FixtureChannel[];
     calibration: { [id: string]: CalibratedCalibrationPoint }
 }
 
+export interface FixtureChannel {
+    id: string;
+    name: string;
+    profileChannel?: string;
+    address: number;
+    fineAddress: number;
+    default: number;
+}
+
 export interface CalibrationPoint {
     id: string;
     name: string;
     x: number;
     y: number;
+    layer?: string;
+    floorX?: number;
+    floorY?: number;
 }
 
 export interface CalibratedCalibrationPoint {
     id: string;
     pan: number;
     tilt: number;
+    channels?: { [channelId: string]: number };
+}
+
+export interface LayerRegion {
+    layer: string;
+    polygon: Point[];
+}
+
+export interface Preset {
+    id: string;
+    name: string;
+    position?: Point;
+    panTilt?: { [fixtureId: string]: { pan: number; tilt: number } };
+}
+
+export interface Cue {
+    id: string;
+    name: string;
+    presetId: string;
+    fadeTime: number;
+    easing: string;
 }
 
 export interface Point {
@@ -45,8 +98,30 @@ export interface SACNConfig {
     fps: number;
     multicast: boolean;
     destinations: string[];
+    universes: SACNUniverseConfig[];
+    stopMode: string;
 }
 
+export interface SACNUniverseConfig {
+    universe: number;
+    protocol: string;
+    inputUniverse: number;
+    mergeMode: string;
+    priority: number;
+    perAddressPriority: boolean;
+    customRouting: boolean;
+    multicast: boolean;
+    destinations: string[];
+}
+
+export interface FixtureControl {
+    mode: string;
+    park?: { pan: number; tilt: number };
+    trim: { pan: number; tilt: number };
+}
+
+export type FixtureControls = { [id: string]: FixtureControl };
+
 export interface Triangle {
     ax: number;
     ay: number;
@@ -1,5 +1,5 @@
 import { main } from "../wailsjs/go/models";
-import type { CalibrationPoint, CalibrationPoints, Fixture, Fixtures, MousePos, Point } from "./types";
+import type { CalibrationPoint, CalibrationPoints, Cue, Fixture, FixtureControls, Fixtures, MousePos, Point, Preset, SACNConfig } from "./types";
 
 export function convexHull(points: CalibrationPoint[]): Point[] {
     if (points.length < 3) return points;
@@ -64,7 +64,8 @@ export function convertFixturesToGo(fixtures: Fixtures, calibrationPoints: Calib
             goCalibration[calibratedRalibrationPointId] = new main.CalibratedCalibrationPoint({
                 Id: calibratedRalibrationPointId,
                 Pan: Math.floor(fixture.calibration[calibratedRalibrationPointId].pan),
-                Tilt: Math.floor(fixture.calibration[calibratedRalibrationPointId].tilt)
+                Tilt: Math.floor(fixture.calibration[calibratedRalibrationPointId].tilt),
+                Channels: fixture.calibration[calibratedRalibrationPointId].channels ?? {}
             });
         }
 
@@ -76,6 +77,33 @@ export function convertFixturesToGo(fixtures: Fixtures, calibrationPoints: Calib
             FinePanAddress: fixture.finePanAddress - 1,
             TiltAddress: fixture.tiltAddress - 1,
             FineTiltAddress: fixture.fineTiltAddress - 1,
+            ProfileId: fixture.profileId ?? "",
+            ProfileMode: fixture.profileMode ?? "",
+            StartAddress: (fixture.startAddress ?? 1) - 1,
+            PanRangeDegrees: fixture.panRangeDegrees ?? 0,
+            PanWrap: fixture.panWrap ?? "none",
+            HomePan: fixture.homePan ?? 32768,
+            HomeTilt: fixture.homeTilt ?? 32768,
+            Target: fixture.target ?? "",
+            EdgeMode: fixture.edgeMode ?? "none",
+            Interpolation: fixture.interpolation ?? "linear",
+            KnownPose: fixture.knownPose,
+            MotionFilter: new main.MotionFilter({
+                Mode: fixture.filterMode ?? "none",
+                SmoothTime: fixture.filterSmoothTime ?? 0.2,
+                MinCutoff: fixture.filterMinCutoff ?? 1,
+                Beta: fixture.filterBeta ?? 1,
+                MaxVelocity: fixture.filterMaxVelocity ?? 0,
+                MaxAcceleration: fixture.filterMaxAcceleration ?? 0,
+            }),
+            Channels: (fixture.channels ?? []).map((channel) => new main.FixtureChannel({
+                Id: channel.id,
+                Name: channel.name,
+                ProfileChannel: channel.profileChannel ?? "",
+                Address: channel.address - 1,
+                FineAddress: channel.fineAddress - 1,
+                Default: channel.default,
+            })),
             Calibration: goCalibration
         });
     }
@@ -91,9 +119,106 @@ export function convertCalibrationPointsToGo(calibrationPoints: CalibrationPoint
             Id: calibrationPoint.id,
             Name: calibrationPoint.name,
             X: calibrationPoint.x,
-            Y: calibrationPoint.y
+            Y: calibrationPoint.y,
+            Layer: calibrationPoint.layer ?? "",
+            HasFloorPosition: calibrationPoint.floorX !== undefined && calibrationPoint.floorY !== undefined,
+            FloorX: calibrationPoint.floorX ?? 0,
+            FloorY: calibrationPoint.floorY ?? 0
         });
     }
 
     return goCalibrationPoints;
 }
+
+export function convertSACNConfigToGo(config: SACNConfig): main.SACNConfig {
+    return new main.SACNConfig({
+        IpAddress: config.ipAddress,
+        PossibleIpAddresses: config.possibleIdAddresses,
+        Fps: config.fps,
+        Multicast: config.multicast,
+        Destinations: config.destinations,
+        StopMode: config.stopMode ?? "hold",
+        Universes: (config.universes ?? []).map((universe) => new main.SACNUniverseConfig({
+            Universe: universe.universe,
+            Protocol: universe.protocol ?? "sacn",
+            InputUniverse: universe.inputUniverse,
+            MergeMode: universe.mergeMode,
+            Priority: universe.priority ?? 100,
+            PerAddressPriority: universe.perAddressPriority ?? false,
+            CustomRouting: universe.customRouting ?? false,
+            Multicast: universe.multicast ?? config.multicast,
+            Destinations: universe.destinations ?? [],
+        })),
+    });
+}
+
+export function convertSACNConfigFromGo(config: main.SACNConfig): SACNConfig {
+    return {
+        ipAddress: config.IpAddress,
+        possibleIdAddresses: config.PossibleIpAddresses,
+        fps: config.Fps,
+        multicast: config.Multicast,
+        destinations: config.Destinations,
+        stopMode: config.StopMode || "hold",
+        universes: (config.Universes ?? []).map((universe) => ({
+            universe: universe.Universe,
+            protocol: universe.Protocol || "sacn",
+            inputUniverse: universe.InputUniverse,
+            mergeMode: universe.MergeMode,
+            priority: universe.Priority || 100,
+            perAddressPriority: universe.PerAddressPriority,
+            customRouting: universe.CustomRouting,
+            multicast: universe.Multicast,
+            destinations: universe.Destinations ?? [],
+        })),
+    };
+}
+
+export function convertFixtureControlsToGo(controls: FixtureControls): { [id: string]: main.FixtureControl } {
+    let goControls: { [id: string]: main.FixtureControl } = {};
+    for (let [id, control] of Object.entries(controls)) {
+        goControls[id] = new main.FixtureControl({
+            Mode: control.mode ?? "follow",
+            Park: control.park ? new main.PanTilt({ Pan: control.park.pan, Tilt: control.park.tilt }) : undefined,
+            Trim: new main.PanTilt({ Pan: control.trim?.pan ?? 0, Tilt: control.trim?.tilt ?? 0 }),
+        });
+    }
+    return goControls;
+}
+
+export function convertFixtureControlsFromGo(controls: { [id: string]: main.FixtureControl }): FixtureControls {
+    let fixtureControls: FixtureControls = {};
+    for (let [id, control] of Object.entries(controls)) {
+        fixtureControls[id] = {
+            mode: control.Mode,
+            park: control.Park ? { pan: control.Park.Pan, tilt: control.Park.Tilt } : undefined,
+            trim: { pan: control.Trim.Pan, tilt: control.Trim.Tilt },
+        };
+    }
+    return fixtureControls;
+}
+
+export function convertPresetsToGo(presets: Preset[]): main.Preset[] {
+    return presets.map((preset) => {
+        let panTilt: { [fixtureId: string]: main.PanTilt } = {};
+        for (let [fixtureId, value] of Object.entries(preset.panTilt ?? {})) {
+            panTilt[fixtureId] = new main.PanTilt({ Pan: value.pan, Tilt: value.tilt });
+        }
+        return new main.Preset({
+            Id: preset.id,
+            Name: preset.name,
+            Position: preset.position ? new main.Point({ X: preset.position.x, Y: preset.position.y }) : undefined,
+            PanTilt: panTilt,
+        });
+    });
+}
+
+export function convertCuesToGo(cues: Cue[]): main.Cue[] {
+    return cues.map((cue) => new main.Cue({
+        Id: cue.id,
+        Name: cue.name,
+        PresetId: cue.presetId,
+        FadeTime: cue.fadeTime ?? 0,
+        Easing: cue.easing ?? "linear",
+    }));
+}
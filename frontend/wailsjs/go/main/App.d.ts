@@ -6,13 +6,49 @@ export function AlertDialog(arg1:string,arg2:string):Promise<void>;
 
 export function ConfirmDialog(arg1:string,arg2:string):Promise<string>;
 
+export function GetActiveCue():Promise<main.ActiveCue>;
+
+export function GetActiveLayer():Promise<string>;
+
+export function GetArtNetNodes():Promise<Array<main.ArtNetNode>>;
+
+export function GetDiscoveredSources():Promise<Array<main.DiscoveredSource>>;
+
+export function GetFixtureControls():Promise<Record<string, main.FixtureControl>>;
+
 export function GetFixturePanTilt():Promise<Record<string, main.PanTilt>>;
 
+export function GetFixturePoses():Promise<Record<string, main.FixturePoseReport>>;
+
+export function GetFixtureProfiles():Promise<Array<main.FixtureProfile>>;
+
 export function GetLastSessionInfo():Promise<main.LastSessionInfo>;
 
+export function GetLayerRegions():Promise<Array<main.LayerRegion>>;
+
+export function GetLayers():Promise<Array<string>>;
+
+export function GetPatchProblems():Promise<Array<string>>;
+
+export function GetRemoteConfig():Promise<main.RemoteConfig>;
+
 export function GetSACNConfig():Promise<main.SACNConfig>;
 
-export function GetTriangles():Promise<Array<main.Triangle>>;
+export function GetSACNMonitor():Promise<Array<main.MonitoredUniverse>>;
+
+export function GetSendStatistics():Promise<Array<main.SendStatistics>>;
+
+export function GetSourceConflicts():Promise<Array<main.SourceConflict>>;
+
+export function GetTargetPositions():Promise<Record<string, main.Point>>;
+
+export function GetTargets():Promise<Array<string>>;
+
+export function GetTriangles():Promise<Record<string, Array<main.Triangle>>>;
+
+export function GoCue(arg1:string):Promise<void>;
+
+export function ImportFixtureProfile():Promise<string>;
 
 export function LoadFile():Promise<string>;
 
@@ -22,18 +58,44 @@ export function Log(arg1:string):Promise<void>;
 
 export function OpenLogFile():Promise<void>;
 
+export function ReleaseCue():Promise<void>;
+
 export function SaveFile(arg1:string):Promise<boolean>;
 
+export function SetActiveLayer(arg1:string):Promise<void>;
+
 export function SetCalibrationPoints(arg1:Record<string, main.CalibrationPoint>):Promise<void>;
 
+export function SetChannelForFixture(arg1:string,arg2:string,arg3:number):Promise<void>;
+
+export function SetCues(arg1:Array<main.Cue>):Promise<void>;
+
+export function SetFixtureControls(arg1:Record<string, main.FixtureControl>):Promise<void>;
+
+export function SetFixtureMode(arg1:string,arg2:string):Promise<void>;
+
+export function SetFixturePark(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function SetFixtureTrim(arg1:string,arg2:number,arg3:number):Promise<void>;
+
 export function SetFixtures(arg1:Record<string, main.Fixture>):Promise<void>;
 
 export function SetLastVideoSource(arg1:string,arg2:string):Promise<void>;
 
+export function SetLayerRegions(arg1:Array<main.LayerRegion>):Promise<void>;
+
+export function SetMonitoredUniverses(arg1:Array<number>):Promise<void>;
+
 export function SetMouseForAllFixtures(arg1:number,arg2:number):Promise<void>;
 
 export function SetPanTiltForFixture(arg1:string,arg2:number,arg3:number):Promise<void>;
 
+export function SetPresets(arg1:Array<main.Preset>):Promise<void>;
+
+export function SetRemoteConfig(arg1:main.RemoteConfig):Promise<void>;
+
 export function SetSACNConfig(arg1:main.SACNConfig):Promise<void>;
 
-export function TypeExporter(arg1:main.CalibrationPoint,arg2:main.CalibratedCalibrationPoint,arg3:main.Fixture,arg4:main.SACNConfig,arg5:main.DMXData,arg6:main.Point,arg7:main.Triangle,arg8:main.PanTilt):Promise<void>;
+export function SetTargetPosition(arg1:string,arg2:number,arg3:number):Promise<void>;
+
+export function TypeExporter(arg1:main.CalibrationPoint,arg2:main.CalibratedCalibrationPoint,arg3:main.Fixture,arg4:main.SACNConfig,arg5:main.DMXData,arg6:main.Point,arg7:main.Triangle,arg8:main.PanTilt,arg9:main.FixturePoseReport,arg10:main.PoseResidual,arg11:main.FixtureControl):Promise<void>;
@@ -10,22 +10,94 @@ export function ConfirmDialog(arg1, arg2) {
   return window['go']['main']['App']['ConfirmDialog'](arg1, arg2);
 }
 
+export function GetActiveCue() {
+  return window['go']['main']['App']['GetActiveCue']();
+}
+
+export function GetActiveLayer() {
+  return window['go']['main']['App']['GetActiveLayer']();
+}
+
+export function GetArtNetNodes() {
+  return window['go']['main']['App']['GetArtNetNodes']();
+}
+
+export function GetDiscoveredSources() {
+  return window['go']['main']['App']['GetDiscoveredSources']();
+}
+
+export function GetFixtureControls() {
+  return window['go']['main']['App']['GetFixtureControls']();
+}
+
 export function GetFixturePanTilt() {
   return window['go']['main']['App']['GetFixturePanTilt']();
 }
 
+export function GetFixturePoses() {
+  return window['go']['main']['App']['GetFixturePoses']();
+}
+
+export function GetFixtureProfiles() {
+  return window['go']['main']['App']['GetFixtureProfiles']();
+}
+
 export function GetLastSessionInfo() {
   return window['go']['main']['App']['GetLastSessionInfo']();
 }
 
+export function GetLayerRegions() {
+  return window['go']['main']['App']['GetLayerRegions']();
+}
+
+export function GetLayers() {
+  return window['go']['main']['App']['GetLayers']();
+}
+
+export function GetPatchProblems() {
+  return window['go']['main']['App']['GetPatchProblems']();
+}
+
+export function GetRemoteConfig() {
+  return window['go']['main']['App']['GetRemoteConfig']();
+}
+
 export function GetSACNConfig() {
   return window['go']['main']['App']['GetSACNConfig']();
 }
 
+export function GetSACNMonitor() {
+  return window['go']['main']['App']['GetSACNMonitor']();
+}
+
+export function GetSendStatistics() {
+  return window['go']['main']['App']['GetSendStatistics']();
+}
+
+export function GetSourceConflicts() {
+  return window['go']['main']['App']['GetSourceConflicts']();
+}
+
+export function GetTargetPositions() {
+  return window['go']['main']['App']['GetTargetPositions']();
+}
+
+export function GetTargets() {
+  return window['go']['main']['App']['GetTargets']();
+}
+
 export function GetTriangles() {
   return window['go']['main']['App']['GetTriangles']();
 }
 
+export function GoCue(arg1) {
+  return window['go']['main']['App']['GoCue'](arg1);
+}
+
+export function ImportFixtureProfile() {
+  return window['go']['main']['App']['ImportFixtureProfile']();
+}
+
 export function LoadFile() {
   return window['go']['main']['App']['LoadFile']();
 }
@@ -42,14 +114,46 @@ export function OpenLogFile() {
   return window['go']['main']['App']['OpenLogFile']();
 }
 
+export function ReleaseCue() {
+  return window['go']['main']['App']['ReleaseCue']();
+}
+
 export function SaveFile(arg1) {
   return window['go']['main']['App']['SaveFile'](arg1);
 }
 
+export function SetActiveLayer(arg1) {
+  return window['go']['main']['App']['SetActiveLayer'](arg1);
+}
+
 export function SetCalibrationPoints(arg1) {
   return window['go']['main']['App']['SetCalibrationPoints'](arg1);
 }
 
+export function SetChannelForFixture(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetChannelForFixture'](arg1, arg2, arg3);
+}
+
+export function SetCues(arg1) {
+  return window['go']['main']['App']['SetCues'](arg1);
+}
+
+export function SetFixtureControls(arg1) {
+  return window['go']['main']['App']['SetFixtureControls'](arg1);
+}
+
+export function SetFixtureMode(arg1, arg2) {
+  return window['go']['main']['App']['SetFixtureMode'](arg1, arg2);
+}
+
+export function SetFixturePark(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetFixturePark'](arg1, arg2, arg3);
+}
+
+export function SetFixtureTrim(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetFixtureTrim'](arg1, arg2, arg3);
+}
+
 export function SetFixtures(arg1) {
   return window['go']['main']['App']['SetFixtures'](arg1);
 }
@@ -58,6 +162,14 @@ export function SetLastVideoSource(arg1, arg2) {
   return window['go']['main']['App']['SetLastVideoSource'](arg1, arg2);
 }
 
+export function SetLayerRegions(arg1) {
+  return window['go']['main']['App']['SetLayerRegions'](arg1);
+}
+
+export function SetMonitoredUniverses(arg1) {
+  return window['go']['main']['App']['SetMonitoredUniverses'](arg1);
+}
+
 export function SetMouseForAllFixtures(arg1, arg2) {
   return window['go']['main']['App']['SetMouseForAllFixtures'](arg1, arg2);
 }
@@ -66,10 +178,22 @@ export function SetPanTiltForFixture(arg1, arg2, arg3) {
   return window['go']['main']['App']['SetPanTiltForFixture'](arg1, arg2, arg3);
 }
 
+export function SetPresets(arg1) {
+  return window['go']['main']['App']['SetPresets'](arg1);
+}
+
+export function SetRemoteConfig(arg1) {
+  return window['go']['main']['App']['SetRemoteConfig'](arg1);
+}
+
 export function SetSACNConfig(arg1) {
   return window['go']['main']['App']['SetSACNConfig'](arg1);
 }
 
-export function TypeExporter(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
-  return window['go']['main']['App']['TypeExporter'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
+export function SetTargetPosition(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetTargetPosition'](arg1, arg2, arg3);
+}
+
+export function TypeExporter(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11) {
+  return window['go']['main']['App']['TypeExporter'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11);
 }
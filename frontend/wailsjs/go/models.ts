@@ -1,9 +1,42 @@
 export namespace main {
 	
+	export class ActiveCue {
+	    CueId: string;
+	    Progress: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ActiveCue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CueId = source["CueId"];
+	        this.Progress = source["Progress"];
+	    }
+	}
+	export class ArtNetNode {
+	    Ip: string;
+	    ShortName: string;
+	    LongName: string;
+	    Universes: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ArtNetNode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Ip = source["Ip"];
+	        this.ShortName = source["ShortName"];
+	        this.LongName = source["LongName"];
+	        this.Universes = source["Universes"];
+	    }
+	}
 	export class CalibratedCalibrationPoint {
 	    Id: string;
 	    Pan: number;
 	    Tilt: number;
+	    Channels: Record<string, number>;
 	
 	    static createFrom(source: any = {}) {
 	        return new CalibratedCalibrationPoint(source);
@@ -14,6 +47,7 @@ export namespace main {
 	        this.Id = source["Id"];
 	        this.Pan = source["Pan"];
 	        this.Tilt = source["Tilt"];
+	        this.Channels = source["Channels"];
 	    }
 	}
 	export class CalibrationPoint {
@@ -21,6 +55,10 @@ export namespace main {
 	    Name: string;
 	    X: number;
 	    Y: number;
+	    Layer: string;
+	    HasFloorPosition: boolean;
+	    FloorX: number;
+	    FloorY: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new CalibrationPoint(source);
@@ -32,6 +70,124 @@ export namespace main {
 	        this.Name = source["Name"];
 	        this.X = source["X"];
 	        this.Y = source["Y"];
+	        this.Layer = source["Layer"];
+	        this.HasFloorPosition = source["HasFloorPosition"];
+	        this.FloorX = source["FloorX"];
+	        this.FloorY = source["FloorY"];
+	    }
+	}
+	export class Cue {
+	    Id: string;
+	    Name: string;
+	    PresetId: string;
+	    FadeTime: number;
+	    Easing: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Cue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Name = source["Name"];
+	        this.PresetId = source["PresetId"];
+	        this.FadeTime = source["FadeTime"];
+	        this.Easing = source["Easing"];
+	    }
+	}
+	export class DiscoveredSource {
+	    CID: string;
+	    Name: string;
+	    Ip: string;
+	    Own: boolean;
+	    Universes: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiscoveredSource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CID = source["CID"];
+	        this.Name = source["Name"];
+	        this.Ip = source["Ip"];
+	        this.Own = source["Own"];
+	        this.Universes = source["Universes"];
+	    }
+	}
+	export class FixtureChannel {
+	    Id: string;
+	    Name: string;
+	    ProfileChannel: string;
+	    Address: number;
+	    FineAddress: number;
+	    Default: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FixtureChannel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Name = source["Name"];
+	        this.ProfileChannel = source["ProfileChannel"];
+	        this.Address = source["Address"];
+	        this.FineAddress = source["FineAddress"];
+	        this.Default = source["Default"];
+	    }
+	}
+	export class MotionFilter {
+	    Mode: string;
+	    SmoothTime: number;
+	    MinCutoff: number;
+	    Beta: number;
+	    MaxVelocity: number;
+	    MaxAcceleration: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MotionFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Mode = source["Mode"];
+	        this.SmoothTime = source["SmoothTime"];
+	        this.MinCutoff = source["MinCutoff"];
+	        this.Beta = source["Beta"];
+	        this.MaxVelocity = source["MaxVelocity"];
+	        this.MaxAcceleration = source["MaxAcceleration"];
+	    }
+	}
+	export class FixturePose {
+	    X: number;
+	    Y: number;
+	    Z: number;
+	    Yaw: number;
+	    Roll: number;
+	    Pitch: number;
+	    PanOffset: number;
+	    PanScale: number;
+	    TiltOffset: number;
+	    TiltScale: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FixturePose(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.X = source["X"];
+	        this.Y = source["Y"];
+	        this.Z = source["Z"];
+	        this.Yaw = source["Yaw"];
+	        this.Roll = source["Roll"];
+	        this.Pitch = source["Pitch"];
+	        this.PanOffset = source["PanOffset"];
+	        this.PanScale = source["PanScale"];
+	        this.TiltOffset = source["TiltOffset"];
+	        this.TiltScale = source["TiltScale"];
 	    }
 	}
 	export class Fixture {
@@ -42,6 +198,19 @@ export namespace main {
 	    FinePanAddress: number;
 	    TiltAddress: number;
 	    FineTiltAddress: number;
+	    ProfileId: string;
+	    ProfileMode: string;
+	    StartAddress: number;
+	    PanRangeDegrees: number;
+	    PanWrap: string;
+	    EdgeMode: string;
+	    Interpolation: string;
+	    KnownPose?: FixturePose;
+	    MotionFilter: MotionFilter;
+	    HomePan: number;
+	    HomeTilt: number;
+	    Target: string;
+	    Channels: FixtureChannel[];
 	    Calibration: Record<string, CalibratedCalibrationPoint>;
 	
 	    static createFrom(source: any = {}) {
@@ -57,6 +226,19 @@ export namespace main {
 	        this.FinePanAddress = source["FinePanAddress"];
 	        this.TiltAddress = source["TiltAddress"];
 	        this.FineTiltAddress = source["FineTiltAddress"];
+	        this.ProfileId = source["ProfileId"];
+	        this.ProfileMode = source["ProfileMode"];
+	        this.StartAddress = source["StartAddress"];
+	        this.PanRangeDegrees = source["PanRangeDegrees"];
+	        this.PanWrap = source["PanWrap"];
+	        this.EdgeMode = source["EdgeMode"];
+	        this.Interpolation = source["Interpolation"];
+	        this.KnownPose = this.convertValues(source["KnownPose"], FixturePose);
+	        this.MotionFilter = this.convertValues(source["MotionFilter"], MotionFilter);
+	        this.HomePan = source["HomePan"];
+	        this.HomeTilt = source["HomeTilt"];
+	        this.Target = source["Target"];
+	        this.Channels = this.convertValues(source["Channels"], FixtureChannel);
 	        this.Calibration = this.convertValues(source["Calibration"], CalibratedCalibrationPoint, true);
 	    }
 	
@@ -78,6 +260,207 @@ export namespace main {
 		    return a;
 		}
 	}
+	
+	export class PanTilt {
+	    Pan: number;
+	    Tilt: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PanTilt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Pan = source["Pan"];
+	        this.Tilt = source["Tilt"];
+	    }
+	}
+	export class FixtureControl {
+	    Mode: string;
+	    Park?: PanTilt;
+	    Trim: PanTilt;
+	
+	    static createFrom(source: any = {}) {
+	        return new FixtureControl(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Mode = source["Mode"];
+	        this.Park = this.convertValues(source["Park"], PanTilt);
+	        this.Trim = this.convertValues(source["Trim"], PanTilt);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class PoseResidual {
+	    Id: string;
+	    Pan: number;
+	    Tilt: number;
+	    Outlier: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PoseResidual(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Pan = source["Pan"];
+	        this.Tilt = source["Tilt"];
+	        this.Outlier = source["Outlier"];
+	    }
+	}
+	export class FixturePoseReport {
+	    Solved: boolean;
+	    Error: string;
+	    Pose: FixturePose;
+	    PanRangeDegrees: number;
+	    TiltRangeDegrees: number;
+	    RMSError: number;
+	    Residuals: Record<string, PoseResidual>;
+	
+	    static createFrom(source: any = {}) {
+	        return new FixturePoseReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Solved = source["Solved"];
+	        this.Error = source["Error"];
+	        this.Pose = this.convertValues(source["Pose"], FixturePose);
+	        this.PanRangeDegrees = source["PanRangeDegrees"];
+	        this.TiltRangeDegrees = source["TiltRangeDegrees"];
+	        this.RMSError = source["RMSError"];
+	        this.Residuals = this.convertValues(source["Residuals"], PoseResidual, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProfileChannel {
+	    Name: string;
+	    Attribute: string;
+	    Offsets: number[];
+	    Default: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfileChannel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Attribute = source["Attribute"];
+	        this.Offsets = source["Offsets"];
+	        this.Default = source["Default"];
+	    }
+	}
+	export class FixtureProfileMode {
+	    Name: string;
+	    Footprint: number;
+	    Channels: ProfileChannel[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FixtureProfileMode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Name = source["Name"];
+	        this.Footprint = source["Footprint"];
+	        this.Channels = this.convertValues(source["Channels"], ProfileChannel);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class FixtureProfile {
+	    Id: string;
+	    Name: string;
+	    Manufacturer: string;
+	    Source: string;
+	    Modes: FixtureProfileMode[];
+	
+	    static createFrom(source: any = {}) {
+	        return new FixtureProfile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Name = source["Name"];
+	        this.Manufacturer = source["Manufacturer"];
+	        this.Source = source["Source"];
+	        this.Modes = this.convertValues(source["Modes"], FixtureProfileMode);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class LastSessionInfo {
 	    hasLastSession: boolean;
 	    configPath: string;
@@ -102,32 +485,205 @@ export namespace main {
 	        this.videoSourceLabel = source["videoSourceLabel"];
 	    }
 	}
-	export class PanTilt {
-	    Pan: number;
-	    Tilt: number;
+	export class Point {
+	    X: number;
+	    Y: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new PanTilt(source);
+	        return new Point(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.Pan = source["Pan"];
-	        this.Tilt = source["Tilt"];
+	        this.X = source["X"];
+	        this.Y = source["Y"];
 	    }
 	}
-	export class Point {
-	    X: number;
-	    Y: number;
+	export class LayerRegion {
+	    Layer: string;
+	    Polygon: Point[];
 	
 	    static createFrom(source: any = {}) {
-	        return new Point(source);
+	        return new LayerRegion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.X = source["X"];
-	        this.Y = source["Y"];
+	        this.Layer = source["Layer"];
+	        this.Polygon = this.convertValues(source["Polygon"], Point);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MonitoredSource {
+	    CID: string;
+	    Name: string;
+	    Ip: string;
+	    Own: boolean;
+	    Active: boolean;
+	    Priority: number;
+	    PerAddressPriority: boolean;
+	    PacketsPerSecond: number;
+	    SequenceErrors: number;
+	    LastSeenMs: number;
+	    Data: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MonitoredSource(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.CID = source["CID"];
+	        this.Name = source["Name"];
+	        this.Ip = source["Ip"];
+	        this.Own = source["Own"];
+	        this.Active = source["Active"];
+	        this.Priority = source["Priority"];
+	        this.PerAddressPriority = source["PerAddressPriority"];
+	        this.PacketsPerSecond = source["PacketsPerSecond"];
+	        this.SequenceErrors = source["SequenceErrors"];
+	        this.LastSeenMs = source["LastSeenMs"];
+	        this.Data = source["Data"];
+	    }
+	}
+	export class MonitoredUniverse {
+	    Universe: number;
+	    Sources: MonitoredSource[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MonitoredUniverse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Universe = source["Universe"];
+	        this.Sources = this.convertValues(source["Sources"], MonitoredSource);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	
+	export class Preset {
+	    Id: string;
+	    Name: string;
+	    Position?: Point;
+	    PanTilt: Record<string, PanTilt>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Preset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Id = source["Id"];
+	        this.Name = source["Name"];
+	        this.Position = this.convertValues(source["Position"], Point);
+	        this.PanTilt = this.convertValues(source["PanTilt"], PanTilt, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RemoteConfig {
+	    OscEnabled: boolean;
+	    OscPort: number;
+	    HttpEnabled: boolean;
+	    HttpPort: number;
+	    ListenAddress: string;
+	    AllowedOrigins: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RemoteConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.OscEnabled = source["OscEnabled"];
+	        this.OscPort = source["OscPort"];
+	        this.HttpEnabled = source["HttpEnabled"];
+	        this.HttpPort = source["HttpPort"];
+	        this.ListenAddress = source["ListenAddress"];
+	        this.AllowedOrigins = source["AllowedOrigins"];
+	    }
+	}
+	export class SACNUniverseConfig {
+	    Universe: number;
+	    Protocol: string;
+	    InputUniverse: number;
+	    MergeMode: string;
+	    Priority: number;
+	    PerAddressPriority: boolean;
+	    CustomRouting: boolean;
+	    Multicast: boolean;
+	    Destinations: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SACNUniverseConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Universe = source["Universe"];
+	        this.Protocol = source["Protocol"];
+	        this.InputUniverse = source["InputUniverse"];
+	        this.MergeMode = source["MergeMode"];
+	        this.Priority = source["Priority"];
+	        this.PerAddressPriority = source["PerAddressPriority"];
+	        this.CustomRouting = source["CustomRouting"];
+	        this.Multicast = source["Multicast"];
+	        this.Destinations = source["Destinations"];
 	    }
 	}
 	export class SACNConfig {
@@ -136,6 +692,8 @@ export namespace main {
 	    Fps: number;
 	    Multicast: boolean;
 	    Destinations: string[];
+	    Universes: SACNUniverseConfig[];
+	    StopMode: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new SACNConfig(source);
@@ -148,6 +706,71 @@ export namespace main {
 	        this.Fps = source["Fps"];
 	        this.Multicast = source["Multicast"];
 	        this.Destinations = source["Destinations"];
+	        this.Universes = this.convertValues(source["Universes"], SACNUniverseConfig);
+	        this.StopMode = source["StopMode"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SendStatistics {
+	    Universe: number;
+	    Protocol: string;
+	    Packets: number;
+	    Errors: number;
+	    LastError: string;
+	    Failing: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SendStatistics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Universe = source["Universe"];
+	        this.Protocol = source["Protocol"];
+	        this.Packets = source["Packets"];
+	        this.Errors = source["Errors"];
+	        this.LastError = source["LastError"];
+	        this.Failing = source["Failing"];
+	    }
+	}
+	export class SourceConflict {
+	    Universe: number;
+	    CID: string;
+	    Name: string;
+	    Ip: string;
+	    Priority: number;
+	    DurationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SourceConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Universe = source["Universe"];
+	        this.CID = source["CID"];
+	        this.Name = source["Name"];
+	        this.Ip = source["Ip"];
+	        this.Priority = source["Priority"];
+	        this.DurationMs = source["DurationMs"];
 	    }
 	}
 	export class Triangle {
@@ -157,6 +780,7 @@ export namespace main {
 	    By: number;
 	    Cx: number;
 	    Cy: number;
+	    Layer: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Triangle(source);
@@ -170,6 +794,7 @@ export namespace main {
 	        this.By = source["By"];
 	        this.Cx = source["Cx"];
 	        this.Cy = source["Cy"];
+	        this.Layer = source["Layer"];
 	    }
 	}
 
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// gdtfDescription is the part of the description.xml in a GDTF file Följe uses, see DIN SPEC 15800.
+type gdtfDescription struct {
+	FixtureType struct {
+		Name         string     `xml:"Name,attr"`
+		LongName     string     `xml:"LongName,attr"`
+		Manufacturer string     `xml:"Manufacturer,attr"`
+		DMXModes     []gdtfMode `xml:"DMXModes>DMXMode"`
+	} `xml:"FixtureType"`
+}
+
+type gdtfMode struct {
+	Name     string        `xml:"Name,attr"`
+	Channels []gdtfChannel `xml:"DMXChannels>DMXChannel"`
+}
+
+type gdtfChannel struct {
+	DMXBreak        string `xml:"DMXBreak,attr"`
+	Offset          string `xml:"Offset,attr"`  // 1-based channels of every byte, most significant first
+	Default         string `xml:"Default,attr"` // GDTF 1.0, later versions have it on the channel function
+	LogicalChannels []struct {
+		Attribute        string `xml:"Attribute,attr"`
+		ChannelFunctions []struct {
+			Attribute string `xml:"Attribute,attr"`
+			Default   string `xml:"Default,attr"`
+		} `xml:"ChannelFunction"`
+	} `xml:"LogicalChannel"`
+}
+
+func parseGDTF(path string) (FixtureProfile, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return FixtureProfile{}, fmt.Errorf("not a GDTF file: %w", err)
+	}
+	defer archive.Close()
+
+	var description gdtfDescription
+	found := false
+	for _, file := range archive.File {
+		if file.Name != "description.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return FixtureProfile{}, err
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return FixtureProfile{}, err
+		}
+		if err := xml.Unmarshal(data, &description); err != nil {
+			return FixtureProfile{}, fmt.Errorf("failed to parse description.xml: %w", err)
+		}
+		found = true
+	}
+	if !found {
+		return FixtureProfile{}, errors.New("not a GDTF file: description.xml is missing")
+	}
+
+	fixtureType := description.FixtureType
+	name := fixtureType.LongName
+	if name == "" {
+		name = fixtureType.Name
+	}
+	profile := FixtureProfile{
+		Name:         name,
+		Manufacturer: fixtureType.Manufacturer,
+		Modes:        make([]FixtureProfileMode, 0, len(fixtureType.DMXModes)),
+	}
+
+	for _, gdtfMode := range fixtureType.DMXModes {
+		mode := FixtureProfileMode{Name: gdtfMode.Name}
+		for _, gdtfChannel := range gdtfMode.Channels {
+			// Channels patched to another DMX break, e.g. a separate LED ring, are not part of this footprint
+			if gdtfChannel.DMXBreak != "" && gdtfChannel.DMXBreak != "1" && gdtfChannel.DMXBreak != "Overwrite" {
+				continue
+			}
+			if gdtfChannel.Offset == "" || len(gdtfChannel.LogicalChannels) == 0 {
+				continue // virtual channel without DMX addresses
+			}
+
+			offsets := make([]int, 0, 2)
+			for _, field := range strings.Split(gdtfChannel.Offset, ",") {
+				channel, err := strconv.Atoi(strings.TrimSpace(field))
+				if err != nil || channel < 1 {
+					return FixtureProfile{}, fmt.Errorf("mode %s has an invalid channel offset %q", gdtfMode.Name, gdtfChannel.Offset)
+				}
+				offsets = append(offsets, channel-1)
+				mode.Footprint = max(mode.Footprint, channel)
+			}
+
+			logical := gdtfChannel.LogicalChannels[0]
+			defaultValue := gdtfChannel.Default
+			if len(logical.ChannelFunctions) > 0 && logical.ChannelFunctions[0].Default != "" {
+				defaultValue = logical.ChannelFunctions[0].Default
+			}
+
+			mode.Channels = append(mode.Channels, ProfileChannel{
+				Name:      logical.Attribute,
+				Attribute: gdtfAttribute(logical.Attribute),
+				Offsets:   offsets,
+				Default:   gdtfDMXValue(defaultValue, len(offsets)),
+			})
+		}
+		profile.Modes = append(profile.Modes, mode)
+	}
+
+	return profile, nil
+}
+
+// gdtfAttribute maps a GDTF attribute to the attribute Följe drives.
+func gdtfAttribute(attribute string) string {
+	switch attribute {
+	case "Pan":
+		return ChannelAttributePan
+	case "Tilt":
+		return ChannelAttributeTilt
+	default:
+		return ""
+	}
+}
+
+// gdtfDMXValue converts a GDTF DMX value like "128/1" (value/bytes) to the given number of bytes.
+func gdtfDMXValue(value string, bytes int) int {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return 0
+	}
+	number, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0
+	}
+	shifting := strings.HasSuffix(parts[1], "s")
+	valueBytes, err := strconv.Atoi(strings.TrimSuffix(parts[1], "s"))
+	if err != nil || valueBytes < 1 {
+		return 0
+	}
+
+	if shifting {
+		// 255/1s is 65280/2
+		return int(math.Floor(number * math.Pow(256, float64(bytes-valueBytes))))
+	}
+
+	// Byte mirroring is the default: 255/1 is 65535/2 and 128/1 is 32896/2
+	maxFrom, maxTo := math.Pow(256, float64(valueBytes))-1, math.Pow(256, float64(bytes))-1
+	return int(math.Round(number / maxFrom * maxTo))
+}
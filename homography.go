@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"math"
+
+	"github.com/fogleman/delaunay"
+)
+
+// Homography is a planar projective transform from video coordinates to floor coordinates,
+// stored row major with the last element fixed to 1.
+type Homography [9]float64
+
+// fitHomography fits a homography mapping src onto dst by least squares. At least four
+// correspondences are needed, more points average out inaccurate clicks. Both point sets are
+// normalized first (Hartley), as the normal equations are badly conditioned on raw coordinates,
+// e.g. floor positions in metres or video positions in pixels.
+func fitHomography(src []Point, dst []Point) (Homography, error) {
+	if len(src) != len(dst) {
+		return Homography{}, errors.New("source and destination points must have the same length")
+	}
+	if len(src) < 4 {
+		return Homography{}, errors.New("at least 4 points with floor positions are needed to fit a homography")
+	}
+	degenerate := errors.New("floor positions are degenerate, make sure no three points are on a line")
+
+	srcNormalization, srcOk := hartleyNormalization(src)
+	dstNormalization, dstOk := hartleyNormalization(dst)
+	if !srcOk || !dstOk {
+		return Homography{}, degenerate
+	}
+
+	A := make([][]float64, 0, 2*len(src))
+	b := make([]float64, 0, 2*len(src))
+	for i := range src {
+		x, y := srcNormalization.applyAffine(src[i])
+		u, v := dstNormalization.applyAffine(dst[i])
+		A = append(A, []float64{x, y, 1, 0, 0, 0, -u * x, -u * y})
+		b = append(b, u)
+		A = append(A, []float64{0, 0, 0, x, y, 1, -v * x, -v * y})
+		b = append(b, v)
+	}
+
+	h, err := leastSquares(A, b)
+	if err != nil {
+		return Homography{}, degenerate
+	}
+
+	// Undo the normalization: H = dst⁻¹ · normalized · src
+	normalized := Homography{h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7], 1}
+	fitted := dstNormalization.inverseAffine().multiply(normalized).multiply(srcNormalization)
+	if math.Abs(fitted[8]) < 1e-12 {
+		return Homography{}, degenerate
+	}
+	for i := range fitted {
+		fitted[i] /= fitted[8]
+	}
+	return fitted, nil
+}
+
+// hartleyNormalization returns the similarity moving the centroid of points to the origin and scaling
+// their mean distance from it to √2, false if the points all coincide.
+func hartleyNormalization(points []Point) (Homography, bool) {
+	var cx, cy float64
+	for _, p := range points {
+		cx += p.X
+		cy += p.Y
+	}
+	cx, cy = cx/float64(len(points)), cy/float64(len(points))
+
+	meanDistance := 0.0
+	for _, p := range points {
+		meanDistance += math.Hypot(p.X-cx, p.Y-cy)
+	}
+	meanDistance /= float64(len(points))
+	if meanDistance < 1e-12 {
+		return Homography{}, false
+	}
+
+	s := math.Sqrt2 / meanDistance
+	return Homography{s, 0, -s * cx, 0, s, -s * cy, 0, 0, 1}, true
+}
+
+// applyAffine maps a point by a homography without perspective, e.g. a normalization.
+func (h Homography) applyAffine(p Point) (float64, float64) {
+	return h[0]*p.X + h[1]*p.Y + h[2], h[3]*p.X + h[4]*p.Y + h[5]
+}
+
+// inverseAffine inverts a normalization from hartleyNormalization.
+func (h Homography) inverseAffine() Homography {
+	s := h[0]
+	return Homography{1 / s, 0, -h[2] / s, 0, 1 / s, -h[5] / s, 0, 0, 1}
+}
+
+// multiply returns the homography applying o first, then h.
+func (h Homography) multiply(o Homography) Homography {
+	var m Homography
+	for row := range 3 {
+		for col := range 3 {
+			for k := range 3 {
+				m[row*3+col] += h[row*3+k] * o[k*3+col]
+			}
+		}
+	}
+	return m
+}
+
+// Apply maps a video point onto the floor. The second return value is the projective scale,
+// its sign tells which side of the horizon the point is on.
+func (h Homography) Apply(p Point) (Point, float64, error) {
+	w := h[6]*p.X + h[7]*p.Y + h[8]
+	if math.Abs(w) < 1e-12 {
+		return Point{}, w, errors.New("point is on the horizon")
+	}
+	return Point{
+		X: (h[0]*p.X + h[1]*p.Y + h[2]) / w,
+		Y: (h[3]*p.X + h[4]*p.Y + h[5]) / w,
+	}, w, nil
+}
+
+// HomographyPanTiltInterpolator maps the video onto the floor with a homography and aims the
+// fixture with a pose fitted to its calibration samples, so it works across the whole floor and
+// not only inside the triangulation.
+type HomographyPanTiltInterpolator struct {
+	*Linear2DPanTiltInterpolator // triangulation used for display, nil when aiming by a known pose alone
+	homography                   Homography
+	floorSide                    float64 // sign of the projective scale on the floor side of the horizon
+	pose                         FixturePose
+	fillValue                    float64
+}
+
+// NewHomographyPanTiltInterpolator solves the fixture pose from its calibration samples, see solveFixturePose.
+// mesh, panValues and tiltValues are the calibration used for the displayed triangulation, mesh may be nil
+// with a known pose. floorPoints are video points on the floor, e.g. those the homography was fitted to.
+func NewHomographyPanTiltInterpolator(mesh *TriangleMesh, panValues []float64, tiltValues []float64, samples []PoseSample, knownPose *FixturePose, homography Homography, floorPoints []Point, fillValue float64) (*HomographyPanTiltInterpolator, error) {
+	var linear *Linear2DPanTiltInterpolator
+	if mesh != nil {
+		var err error
+		linear, err = NewLinear2DPanTiltInterpolator(mesh, panValues, tiltValues, fillValue, EdgeModeNone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	floorSide := 0.0
+	for _, point := range floorPoints {
+		_, w, err := homography.Apply(point)
+		if err == nil {
+			floorSide += w
+		}
+	}
+
+	solution, err := solveFixturePose(samples, knownPose)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HomographyPanTiltInterpolator{
+		Linear2DPanTiltInterpolator: linear,
+		homography:                  homography,
+		floorSide:                   math.Copysign(1, floorSide),
+		pose:                        solution.Pose,
+		fillValue:                   fillValue,
+	}, nil
+}
+
+func (interp *HomographyPanTiltInterpolator) FillValue() float64 {
+	return interp.fillValue
+}
+
+func (interp *HomographyPanTiltInterpolator) Triangles() []Triangle {
+	if interp.Linear2DPanTiltInterpolator == nil {
+		return nil
+	}
+	return interp.Linear2DPanTiltInterpolator.Triangles()
+}
+
+func (interp *HomographyPanTiltInterpolator) Interpolate(point delaunay.Point) (float64, float64, error) {
+	floor, w, err := interp.homography.Apply(Point{X: point.X, Y: point.Y})
+	if err != nil || w*interp.floorSide < 0 {
+		return interp.fillValue, interp.fillValue, errOutsideCalibration // point is above the horizon
+	}
+
+	// Pan is clamped after picking the turn of wrapping fixtures, see panTiltAt
+	pan, tilt := interp.pose.PanTilt(floor)
+	return pan, clampPanTiltValue(tilt), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fogleman/delaunay"
+)
+
+func TestFitHomographyRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		homography Homography
+		frame      Point // size of the video, positions are 0-1 on both axes if 1 by 1
+	}{
+		{"identity", Homography{1, 0, 0, 0, 1, 0, 0, 0, 1}, Point{X: 1, Y: 1}},
+		{"scale and offset", Homography{8, 0, -4, 0, 6, 1, 0, 0, 1}, Point{X: 1, Y: 1}},
+		{"camera looking down the stage", Homography{10, 2, -5, 0.5, 12, -1, 0.1, 0.6, 1}, Point{X: 1, Y: 1}},
+		{"pixels to millimetres", Homography{10, 2, -5000, 0.5, 12, -1000, 1e-4, 6e-4, 1}, Point{X: 1920, Y: 1080}},
+	}
+	normalizedVideo := []Point{{X: 0.1, Y: 0.1}, {X: 0.9, Y: 0.15}, {X: 0.85, Y: 0.9}, {X: 0.2, Y: 0.8}, {X: 0.5, Y: 0.5}, {X: 0.3, Y: 0.4}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			video := make([]Point, len(normalizedVideo))
+			for i, p := range normalizedVideo {
+				video[i] = Point{X: test.frame.X * p.X, Y: test.frame.Y * p.Y}
+			}
+			floor := make([]Point, len(video))
+			for i, p := range video {
+				var err error
+				if floor[i], _, err = test.homography.Apply(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			fitted, err := fitHomography(video, floor)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range fitted {
+				if math.Abs(fitted[i]-test.homography[i]) > 1e-9*math.Max(1, math.Abs(test.homography[i])) {
+					t.Fatalf("fitted %v, want %v", fitted, test.homography)
+				}
+			}
+		})
+	}
+}
+
+func TestFitHomographyRejectsDegeneratePoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst []Point
+	}{
+		{"too few", []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}, []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}},
+		{"on a line", []Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 3}}, []Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 3}}},
+		{"mismatched", []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}}, []Point{{X: 0, Y: 0}}},
+	}
+	for _, test := range tests {
+		if _, err := fitHomography(test.src, test.dst); err == nil {
+			t.Errorf("%s: fitted a homography", test.name)
+		}
+	}
+}
+
+func TestHomographyInterpolatorWithKnownPoseOnly(t *testing.T) {
+	homography := Homography{10, 0, 0, 0, 8, 0, 0, 0, 1} // 0-1 on the video is a 10 by 8 m stage
+	pose := FixturePose{X: 5, Y: -2, Z: 6, Yaw: math.Pi / 2, PanOffset: 32768, PanScale: 65535 / (3 * math.Pi), TiltOffset: 20000, TiltScale: 65535 / (1.5 * math.Pi)}
+	video := []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+
+	interp, err := NewHomographyPanTiltInterpolator(nil, nil, nil, nil, &pose, homography, video, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interp.Triangles() != nil || interp.FillValue() != -1 {
+		t.Errorf("triangles %v, fill value %v without calibration", interp.Triangles(), interp.FillValue())
+	}
+
+	gotPan, gotTilt, err := interp.Interpolate(delaunay.Point{X: 0.5, Y: 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPan, wantTilt := pose.PanTilt(Point{X: 5, Y: 2})
+	if math.Abs(gotPan-wantPan) > 1e-6 || math.Abs(gotTilt-wantTilt) > 1e-6 {
+		t.Errorf("aims at %v, %v, want %v, %v", gotPan, gotTilt, wantPan, wantTilt)
+	}
+}
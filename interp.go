@@ -2,16 +2,48 @@ package main
 
 import (
 	"errors"
+	"math"
 
 	"github.com/fogleman/delaunay"
 )
 
+// Edge modes decide what Interpolate returns for points outside the convex hull of the calibration points.
+const (
+	EdgeModeNone    = "none"    // return the fill value, the fixture stops following
+	EdgeModeClamp   = "clamp"   // use the value at the closest point on the hull
+	EdgeModeExtend  = "extend"  // extend the plane of the closest boundary triangle
+	EdgeModeNearest = "nearest" // use the value of the closest calibration point
+)
+
+// Interpolation modes select how pan/tilt is interpolated between calibration points.
+const (
+	InterpolationLinear       = "linear"        // barycentric, piecewise linear over the triangulation
+	InterpolationCloughTocher = "clough-tocher" // piecewise cubic with continuous first derivatives
+	InterpolationHomography   = "homography"    // camera to floor homography and a fitted fixture pose
+)
+
+const maxPanTiltValue = 65535
+
+// errOutsideCalibration is returned by Interpolate for points the calibration does not cover, e.g.
+// outside the hull with edge mode none. Pan and tilt are not clamped, so any value may be a real result.
+var errOutsideCalibration = errors.New("point is outside the calibration")
+
+// PanTiltInterpolator maps a position on the video to pan/tilt values for a fixture.
+type PanTiltInterpolator interface {
+	// Interpolate returns pan/tilt for the point, or errOutsideCalibration if the point can not be
+	// handled. FillValue is returned for both along with any error.
+	Interpolate(point delaunay.Point) (float64, float64, error)
+	FillValue() float64
+	// Triangles returns the triangulation of the calibration points the interpolator works on.
+	Triangles() []Triangle
+}
+
 type Linear2DPanTiltInterpolator struct {
-	points     []delaunay.Point
-	tri        *delaunay.Triangulation
+	*TriangleMesh
 	panValues  []float64
 	tiltValues []float64
 	fillValue  float64
+	edgeMode   string
 }
 
 // cross returns the cross product of vectors (b-a) and (p-a).
@@ -32,22 +64,9 @@ func pointInTriangle(a, b, c, p delaunay.Point) bool {
 	return !(hasNeg && hasPos)
 }
 
+// LocatePoint returns the index of the triangle containing p, see TriangleMesh.Locate.
 func (interp *Linear2DPanTiltInterpolator) LocatePoint(p delaunay.Point) (int, error) {
-	numEdges := len(interp.tri.Triangles)
-	if numEdges == 0 {
-		return -1, errors.New("no triangles in the triangulation")
-	}
-
-	for t := 0; t < numEdges; t += 3 {
-		a := interp.points[interp.tri.Triangles[t]]
-		b := interp.points[interp.tri.Triangles[t+1]]
-		c := interp.points[interp.tri.Triangles[t+2]]
-		if pointInTriangle(a, b, c, p) {
-			return t / 3, nil
-		}
-	}
-
-	return -1, errors.New("point is outside the convex hull")
+	return interp.Locate(p)
 }
 
 func (interp *Linear2DPanTiltInterpolator) Barycentric(point delaunay.Point, triangle int) (float64, float64, float64, error) {
@@ -80,36 +99,55 @@ func (interp *Linear2DPanTiltInterpolator) Barycentric(point delaunay.Point, tri
 	return l1, l2, l3, nil
 }
 
-func NewLinear2DPanTiltInterpolator(points []Point, panValues []float64, tiltValues []float64, fillValue float64) (*Linear2DPanTiltInterpolator, error) {
-	if len(points) == 0 || len(panValues) == 0 || len(tiltValues) == 0 || len(points) != len(panValues) || len(points) != len(tiltValues) {
+// NewLinear2DPanTiltInterpolator interpolates over mesh, panValues and tiltValues are the calibration at
+// each of its points. The mesh is not modified and can be shared between fixtures.
+func NewLinear2DPanTiltInterpolator(mesh *TriangleMesh, panValues []float64, tiltValues []float64, fillValue float64, edgeMode string) (*Linear2DPanTiltInterpolator, error) {
+	if mesh == nil || len(panValues) == 0 || len(tiltValues) == 0 || len(mesh.points) != len(panValues) || len(mesh.points) != len(tiltValues) {
 		return nil, errors.New("points and values must have the same non-zero length")
 	}
 
-	delaunayPoints := make([]delaunay.Point, len(points))
-	for i, point := range points {
-		delaunayPoints[i] = delaunay.Point{X: point.X, Y: point.Y}
-	}
-
-	tri, err := delaunay.Triangulate(delaunayPoints)
-	if err != nil {
-		return nil, err
-	}
-
 	return &Linear2DPanTiltInterpolator{
-		points:     delaunayPoints,
-		tri:        tri,
-		panValues:  panValues,
-		tiltValues: tiltValues,
-		fillValue:  fillValue,
+		TriangleMesh: mesh,
+		panValues:    panValues,
+		tiltValues:   tiltValues,
+		fillValue:    fillValue,
+		edgeMode:     edgeMode,
 	}, nil
 }
 
+func (interp *Linear2DPanTiltInterpolator) FillValue() float64 {
+	return interp.fillValue
+}
+
+func (interp *Linear2DPanTiltInterpolator) Triangles() []Triangle {
+	numTriangles := len(interp.tri.Triangles) / 3
+	triangles := make([]Triangle, 0, numTriangles)
+	for i := 0; i < numTriangles; i++ {
+		idx := i * 3
+		a := interp.points[interp.tri.Triangles[idx]]
+		b := interp.points[interp.tri.Triangles[idx+1]]
+		c := interp.points[interp.tri.Triangles[idx+2]]
+		triangles = append(triangles, Triangle{
+			Ax: a.X, Ay: a.Y,
+			Bx: b.X, By: b.Y,
+			Cx: c.X, Cy: c.Y,
+		})
+	}
+	return triangles
+}
+
 func (interp *Linear2DPanTiltInterpolator) Interpolate(point delaunay.Point) (float64, float64, error) {
 	triangle, err := interp.LocatePoint(point)
 	if err != nil {
-		return interp.fillValue, interp.fillValue, nil // point is outside the convex hull
+		return interp.extrapolate(point, interp.interpolateInTriangle) // point is outside the convex hull
 	}
 
+	return interp.interpolateInTriangle(point, triangle)
+}
+
+// interpolateInTriangle evaluates the plane of the given triangle at point. The point does not
+// have to lie inside the triangle, outside it the plane is extended linearly.
+func (interp *Linear2DPanTiltInterpolator) interpolateInTriangle(point delaunay.Point, triangle int) (float64, float64, error) {
 	baryDist1, baryDist2, baryDist3, err := interp.Barycentric(point, triangle)
 	if err != nil {
 		return interp.fillValue, interp.fillValue, errors.New("triangle vertices are collinear; denominator is zero")
@@ -135,3 +173,103 @@ func (interp *Linear2DPanTiltInterpolator) Interpolate(point delaunay.Point) (fl
 	tilt := baryDist1*interp.tiltValues[idx0] + baryDist2*interp.tiltValues[idx1] + baryDist3*interp.tiltValues[idx2]
 	return pan, tilt, nil
 }
+
+// triangleEvaluator evaluates an interpolant at a point using the given triangle.
+type triangleEvaluator func(point delaunay.Point, triangle int) (float64, float64, error)
+
+// extrapolate returns pan/tilt for a point outside the convex hull according to the edge mode.
+// evaluate is the interpolant used inside the hull, so the result is continuous across the hull.
+func (interp *Linear2DPanTiltInterpolator) extrapolate(point delaunay.Point, evaluate triangleEvaluator) (float64, float64, error) {
+	switch interp.edgeMode {
+	case EdgeModeClamp, EdgeModeExtend:
+		edge, t, ok := interp.nearestHullEdge(point)
+		if !ok {
+			return interp.fillValue, interp.fillValue, errOutsideCalibration
+		}
+		a := interp.points[interp.tri.Triangles[edge]]
+		b := interp.points[interp.tri.Triangles[nextHalfedge(edge)]]
+		onHull := delaunay.Point{X: a.X + t*(b.X-a.X), Y: a.Y + t*(b.Y-a.Y)}
+		pan, tilt, err := evaluate(onHull, edge/3)
+		if err != nil || interp.edgeMode == EdgeModeClamp {
+			return pan, tilt, err
+		}
+
+		// Continue from the hull with the slope of the boundary triangle's plane. Pan is clamped after
+		// picking the turn of wrapping fixtures, see panTiltAt
+		planePan, planeTilt, err := interp.interpolateInTriangle(point, edge/3)
+		if err != nil {
+			return interp.fillValue, interp.fillValue, err
+		}
+		hullPan, hullTilt, err := interp.interpolateInTriangle(onHull, edge/3)
+		if err != nil {
+			return interp.fillValue, interp.fillValue, err
+		}
+		return pan + planePan - hullPan, clampPanTiltValue(tilt + planeTilt - hullTilt), nil
+	case EdgeModeNearest:
+		nearest := -1
+		bestDist := math.Inf(1)
+		for i, p := range interp.points {
+			dist := (p.X-point.X)*(p.X-point.X) + (p.Y-point.Y)*(p.Y-point.Y)
+			if dist < bestDist {
+				nearest = i
+				bestDist = dist
+			}
+		}
+		if nearest < 0 {
+			return interp.fillValue, interp.fillValue, errOutsideCalibration
+		}
+		return interp.panValues[nearest], interp.tiltValues[nearest], nil
+	default:
+		return interp.fillValue, interp.fillValue, errOutsideCalibration
+	}
+}
+
+// nearestHullEdge finds the boundary halfedge closest to point. It also returns where along the
+// edge (0 at its start, 1 at its end) the closest point lies.
+func (interp *Linear2DPanTiltInterpolator) nearestHullEdge(point delaunay.Point) (int, float64, bool) {
+	bestEdge := -1
+	bestT := 0.0
+	bestDist := math.Inf(1)
+	for e, opposite := range interp.tri.Halfedges {
+		if opposite != -1 {
+			continue // edge is shared by two triangles, so it is not on the hull
+		}
+
+		a := interp.points[interp.tri.Triangles[e]]
+		b := interp.points[interp.tri.Triangles[nextHalfedge(e)]]
+		t, dist := projectOntoSegment(a, b, point)
+		if dist < bestDist {
+			bestEdge = e
+			bestT = t
+			bestDist = dist
+		}
+	}
+
+	return bestEdge, bestT, bestEdge >= 0
+}
+
+// nextHalfedge returns the next halfedge in the same triangle.
+func nextHalfedge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// projectOntoSegment returns how far along segment ab the point closest to p lies (0-1) and the
+// squared distance from p to that point.
+func projectOntoSegment(a, b, p delaunay.Point) (float64, float64) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSquared := dx*dx + dy*dy
+	t := 0.0
+	if lengthSquared > 0 {
+		t = ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lengthSquared
+		t = math.Max(0, math.Min(1, t))
+	}
+	cx, cy := a.X+t*dx-p.X, a.Y+t*dy-p.Y
+	return t, cx*cx + cy*cy
+}
+
+func clampPanTiltValue(value float64) float64 {
+	return math.Max(0, math.Min(maxPanTiltValue, value))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/fogleman/delaunay"
+)
+
+func TestExtrapolateOutsideHull(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pan = 10000 + 20000x, tilt = 20000 + 10000y
+	panValues := []float64{10000, 30000, 10000, 30000}
+	tiltValues := []float64{20000, 20000, 30000, 30000}
+
+	tests := []struct {
+		edgeMode  string
+		x, y      float64
+		pan, tilt float64 // NaN if the point is outside the calibration
+	}{
+		{EdgeModeNone, 1.5, 0.2, math.NaN(), math.NaN()},
+		{EdgeModeClamp, 1.5, 0.2, 30000, 22000},
+		{EdgeModeClamp, -0.5, -0.5, 10000, 20000},
+		{EdgeModeClamp, 0.5, 3, 20000, 30000},
+		{EdgeModeExtend, 1.5, 0.2, 40000, 22000},
+		{EdgeModeExtend, -0.5, -0.5, 0, 15000},
+		{EdgeModeExtend, 0.5, 5, 20000, maxPanTiltValue}, // tilt is clamped
+		{EdgeModeExtend, 4, 0.5, 90000, 25000},           // pan is left for panTiltAt to wrap and clamp
+		{EdgeModeNearest, 1.5, 0.2, 30000, 20000},
+		{EdgeModeNearest, -0.5, 1.2, 10000, 30000},
+		{EdgeModeNearest, 0.6, -2, 30000, 20000},
+	}
+	for _, test := range tests {
+		interp, err := NewLinear2DPanTiltInterpolator(mesh, panValues, tiltValues, -1, test.edgeMode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pan, tilt, err := interp.Interpolate(delaunay.Point{X: test.x, Y: test.y})
+		if math.IsNaN(test.pan) {
+			if !errors.Is(err, errOutsideCalibration) {
+				t.Errorf("%s (%v, %v) = %v, %v, %v, want outside the calibration", test.edgeMode, test.x, test.y, pan, tilt, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s (%v, %v): %s", test.edgeMode, test.x, test.y, err)
+		}
+		if math.Abs(pan-test.pan) > 1e-6 || math.Abs(tilt-test.tilt) > 1e-6 {
+			t.Errorf("%s (%v, %v) = %v, %v, want %v, %v", test.edgeMode, test.x, test.y, pan, tilt, test.pan, test.tilt)
+		}
+	}
+}
+
+func TestNearestHullEdge(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewLinear2DPanTiltInterpolator(mesh, []float64{0, 0, 0}, []float64{0, 0, 0}, -1, EdgeModeClamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		x, y               float64
+		closestX, closestY float64
+	}{
+		{1, 1, 0.5, 0.5},  // the hypotenuse
+		{0.3, -1, 0.3, 0}, // the bottom edge
+		{-2, 0.6, 0, 0.6}, // the left edge
+		{2, -1, 1, 0},     // past the end of two edges, the corner
+		{-1, -1, 0, 0},    // the opposite corner
+	}
+	for _, test := range tests {
+		edge, along, ok := interp.nearestHullEdge(delaunay.Point{X: test.x, Y: test.y})
+		if !ok {
+			t.Fatalf("(%v, %v): no hull edge", test.x, test.y)
+		}
+		if interp.tri.Halfedges[edge] != -1 {
+			t.Errorf("(%v, %v): edge %d is not on the hull", test.x, test.y, edge)
+		}
+		a := interp.points[interp.tri.Triangles[edge]]
+		b := interp.points[interp.tri.Triangles[nextHalfedge(edge)]]
+		x, y := a.X+along*(b.X-a.X), a.Y+along*(b.Y-a.Y)
+		if math.Abs(x-test.closestX) > 1e-9 || math.Abs(y-test.closestY) > 1e-9 {
+			t.Errorf("(%v, %v): closest point on the hull (%v, %v), want (%v, %v)", test.x, test.y, x, y, test.closestX, test.closestY)
+		}
+	}
+}
+
+// A genuine result equal to the fill value, here an extrapolated pan of -1, must not be taken as
+// outside the calibration.
+func TestPanTiltAtAcceptsFillValueAsResult(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewLinear2DPanTiltInterpolator(mesh, []float64{1, 3, 1, 3}, []float64{20000, 20000, 20000, 20000}, -1, EdgeModeExtend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{linearInterpolators: map[string]map[string]PanTiltInterpolator{defaultLayer: {"spot": interp}}}
+
+	got, ok := a.panTiltAt(Fixture{Id: "spot"}, defaultLayer, Point{X: -1, Y: 0.5})
+	if !ok || got != (PanTilt{Pan: 0, Tilt: 20000}) {
+		t.Errorf("panTiltAt = %v, %v, want {0 20000}, true", got, ok)
+	}
+}
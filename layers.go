@@ -0,0 +1,92 @@
+package main
+
+import (
+	"sort"
+)
+
+// defaultLayer is the calibration layer of points without a layer, e.g. from older save files.
+const defaultLayer = "floor"
+
+func layerName(layer string) string {
+	if layer == "" {
+		return defaultLayer
+	}
+	return layer
+}
+
+// calibrationPointsByLayer groups the calibration points by their layer.
+func (a *App) calibrationPointsByLayer() map[string]map[string]CalibrationPoint {
+	layers := make(map[string]map[string]CalibrationPoint)
+	for id, calibrationPoint := range a.calibrationPoints {
+		layer := layerName(calibrationPoint.Layer)
+		if layers[layer] == nil {
+			layers[layer] = make(map[string]CalibrationPoint)
+		}
+		layers[layer][id] = calibrationPoint
+	}
+	return layers
+}
+
+// layerAt returns the layer of the first region containing p, or the active layer if there is none.
+func (a *App) layerAt(p Point) string {
+	for _, region := range a.layerRegions {
+		if pointInPolygon(p, region.Polygon) {
+			return layerName(region.Layer)
+		}
+	}
+	return a.activeLayer
+}
+
+// pointInPolygon returns true if p lies inside the polygon, using the even-odd rule.
+func pointInPolygon(p Point, polygon []Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// SetActiveLayer selects the calibration layer used where no layer region applies.
+func (a *App) SetActiveLayer(layer string) {
+	LogInfo("SetActiveLayer: %s", layerName(layer))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.activeLayer = layerName(layer)
+}
+
+func (a *App) GetActiveLayer() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.activeLayer
+}
+
+// GetLayers returns the names of all layers which have calibration points.
+func (a *App) GetLayers() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	layers := make([]string, 0)
+	for layer := range a.calibrationPointsByLayer() {
+		layers = append(layers, layer)
+	}
+	sort.Strings(layers)
+	return layers
+}
+
+// SetLayerRegions sets the areas of the video which use a specific layer regardless of the active
+// layer. Regions are checked in order, the first one containing the position wins.
+func (a *App) SetLayerRegions(regions []LayerRegion) {
+	LogInfo("SetLayerRegions: %d region(s)", len(regions))
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.layerRegions = regions
+}
+
+func (a *App) GetLayerRegions() []LayerRegion {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.layerRegions
+}
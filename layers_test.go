@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestPointInPolygon(t *testing.T) {
+	square := []Point{{X: 0.2, Y: 0.2}, {X: 0.6, Y: 0.2}, {X: 0.6, Y: 0.6}, {X: 0.2, Y: 0.6}}
+	// A U shape, the notch between its arms is outside
+	u := []Point{{X: 0, Y: 0}, {X: 0.3, Y: 0}, {X: 0.3, Y: 0.6}, {X: 0.6, Y: 0.6}, {X: 0.6, Y: 0}, {X: 0.9, Y: 0}, {X: 0.9, Y: 1}, {X: 0, Y: 1}}
+
+	tests := []struct {
+		name    string
+		polygon []Point
+		p       Point
+		inside  bool
+	}{
+		{"square centre", square, Point{X: 0.4, Y: 0.4}, true},
+		{"left of the square", square, Point{X: 0.1, Y: 0.4}, false},
+		{"right of the square", square, Point{X: 0.7, Y: 0.4}, false},
+		{"above the square", square, Point{X: 0.4, Y: 0.1}, false},
+		{"below the square", square, Point{X: 0.4, Y: 0.7}, false},
+		{"arm of the U", u, Point{X: 0.1, Y: 0.3}, true},
+		{"other arm of the U", u, Point{X: 0.8, Y: 0.3}, true},
+		{"notch of the U", u, Point{X: 0.45, Y: 0.3}, false},
+		{"base of the U", u, Point{X: 0.45, Y: 0.8}, true},
+		{"too few points", []Point{{X: 0, Y: 0}, {X: 1, Y: 1}}, Point{X: 0.5, Y: 0.5}, false},
+		{"empty", nil, Point{X: 0.5, Y: 0.5}, false},
+	}
+	for _, test := range tests {
+		if got := pointInPolygon(test.p, test.polygon); got != test.inside {
+			t.Errorf("%s: pointInPolygon(%v) = %v, want %v", test.name, test.p, got, test.inside)
+		}
+	}
+}
+
+func TestLayerAt(t *testing.T) {
+	a := &App{
+		activeLayer: "stage",
+		layerRegions: []LayerRegion{
+			{Layer: "riser", Polygon: []Point{{X: 0.5, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 0.5}, {X: 0.5, Y: 0.5}}},
+			// Overlaps the riser, which comes first
+			{Layer: "", Polygon: []Point{{X: 0, Y: 0}, {X: 0.75, Y: 0}, {X: 0.75, Y: 0.75}, {X: 0, Y: 0.75}}},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		p     Point
+		layer string
+	}{
+		{"only in the first region", Point{X: 0.9, Y: 0.1}, "riser"},
+		{"in both, the first wins", Point{X: 0.6, Y: 0.25}, "riser"},
+		{"only in the second region, without a layer", Point{X: 0.25, Y: 0.25}, defaultLayer},
+		{"in no region", Point{X: 0.9, Y: 0.9}, "stage"},
+	}
+	for _, test := range tests {
+		if got := a.layerAt(test.p); got != test.layer {
+			t.Errorf("%s: layerAt(%v) = %s, want %s", test.name, test.p, got, test.layer)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+// solveLinearSystem solves A x = b with Gaussian elimination and partial pivoting.
+// A and b are modified in place.
+func solveLinearSystem(A [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	if len(A) != n {
+		return nil, errors.New("matrix and vector dimensions do not match")
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(A[row][col]) > math.Abs(A[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(A[pivot][col]) < 1e-12 {
+			return nil, errors.New("matrix is singular")
+		}
+		A[col], A[pivot] = A[pivot], A[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		for row := col + 1; row < n; row++ {
+			factor := A[row][col] / A[col][col]
+			for k := col; k < n; k++ {
+				A[row][k] -= factor * A[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= A[row][k] * x[k]
+		}
+		x[row] = sum / A[row][row]
+	}
+	return x, nil
+}
+
+// leastSquares returns the x minimising |A x - b| by solving the normal equations.
+func leastSquares(A [][]float64, b []float64) ([]float64, error) {
+	if len(A) == 0 || len(A) != len(b) {
+		return nil, errors.New("matrix and vector dimensions do not match")
+	}
+
+	n := len(A[0])
+	if len(A) < n {
+		return nil, errors.New("not enough equations for the number of unknowns")
+	}
+
+	ata := make([][]float64, n)
+	atb := make([]float64, n)
+	for i := 0; i < n; i++ {
+		ata[i] = make([]float64, n)
+		for j := 0; j < n; j++ {
+			for r := range A {
+				ata[i][j] += A[r][i] * A[r][j]
+			}
+		}
+		for r := range A {
+			atb[i] += A[r][i] * b[r]
+		}
+	}
+
+	return solveLinearSystem(ata, atb)
+}
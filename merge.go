@@ -0,0 +1,414 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"gitlab.com/patopest/go-sacn"
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// Merge modes decide how an output universe combines Följe's followed channels with the console's
+// data received on the input universe. Channels Följe does not follow always come from the console.
+const (
+	MergeModeOff      = "off"      // Följe owns the universe, channels it does not follow are sent as 0
+	MergeModeOverride = "override" // followed channels always come from Följe
+	MergeModeHTP      = "htp"      // followed channels use the highest of the console and Följe
+	MergeModeLTP      = "ltp"      // followed channels use whichever of the console and Följe changed last
+)
+
+// sacnReceiverRetryInterval is how long to wait before creating the sACN receiver or joining a
+// universe again after it failed.
+const sacnReceiverRetryInterval = 5 * time.Second
+
+// sacnInputTimeout is how long a source on an input universe takes part in the merge after its last packet.
+const sacnInputTimeout = sacn.NETWORK_DATA_LOSS_TIMEOUT * time.Millisecond
+
+// sacnInput is the data received on an input universe, merged from its sources as an E1.31 receiver
+// does: only the sources with the highest priority count, and the highest value among them wins.
+type sacnInput struct {
+	data    DMXData
+	changed [512]time.Time // when each channel last changed
+	sources map[[16]byte]*sacnInputSource
+}
+
+// sacnInputSource is the latest data from one source, by CID, on an input universe.
+type sacnInputSource struct {
+	priority uint8
+	data     DMXData
+	lastSeen time.Time
+}
+
+// receive stores the data of a source and merges the sources again. Sources which stopped sending are
+// dropped, the sender itself is always current so the last data is held if every other source stops.
+func (input *sacnInput) receive(cid [16]byte, priority uint8, values []byte, now time.Time) {
+	if input.sources == nil {
+		input.sources = make(map[[16]byte]*sacnInputSource)
+	}
+	source, exists := input.sources[cid]
+	if !exists {
+		source = &sacnInputSource{}
+		input.sources[cid] = source
+	}
+	source.priority = priority
+	source.data = DMXData{}
+	copy(source.data[:], values)
+	source.lastSeen = now
+
+	var top uint8
+	for id, source := range input.sources {
+		if now.Sub(source.lastSeen) > sacnInputTimeout {
+			delete(input.sources, id)
+		} else if source.priority > top {
+			top = source.priority
+		}
+	}
+
+	merged := DMXData{}
+	for _, source := range input.sources {
+		if source.priority != top {
+			continue
+		}
+		for i, value := range source.data {
+			if value > merged[i] {
+				merged[i] = value
+			}
+		}
+	}
+	for i, value := range merged {
+		if input.data[i] != value || input.changed[i].IsZero() {
+			input.data[i] = value
+			input.changed[i] = now
+		}
+	}
+}
+
+// followedOutput is what Följe last wrote to an output universe, to tell when its channels changed for LTP.
+type followedOutput struct {
+	data    DMXData
+	changed [512]time.Time
+}
+
+func merging(config SACNUniverseConfig) bool {
+	return config.InputUniverse != 0 && config.MergeMode != "" && config.MergeMode != MergeModeOff
+}
+
+// followedChannel is a channel Följe drives in a universe, merged as one value with its fine byte.
+type followedChannel struct {
+	coarse, fine int // fine is -1 for 8 bit channels
+}
+
+// value returns the value of the channel in data at its full resolution.
+func (channel followedChannel) value(data *DMXData) int {
+	if channel.fine < 0 {
+		return int(data[channel.coarse])
+	}
+	return int(data[channel.coarse])<<8 | int(data[channel.fine])
+}
+
+// changed returns when either byte of the channel last changed.
+func (channel followedChannel) changed(changed *[512]time.Time) time.Time {
+	if channel.fine >= 0 && changed[channel.fine].After(changed[channel.coarse]) {
+		return changed[channel.fine]
+	}
+	return changed[channel.coarse]
+}
+
+// copyTo copies both bytes of the channel from src to dst.
+func (channel followedChannel) copyTo(dst *DMXData, src *DMXData) {
+	dst[channel.coarse] = src[channel.coarse]
+	if channel.fine >= 0 {
+		dst[channel.fine] = src[channel.fine]
+	}
+}
+
+// followedChannels returns the channels in the universe Följe drives: pan/tilt and the channels of
+// the fixtures which are not released.
+func (a *App) followedChannels(uni uint16) []followedChannel {
+	inUniverse := func(address int) bool { return address >= 0 && address < 512 }
+	channels := make([]followedChannel, 0)
+	for _, fixture := range a.fixtures {
+		if fixture.Universe != uni || a.fixtureControls[fixture.Id].mode() == FixtureModeRelease {
+			continue
+		}
+		candidates := []followedChannel{{fixture.PanAddress, fixture.FinePanAddress}, {fixture.TiltAddress, fixture.FineTiltAddress}}
+		for _, channel := range fixture.Channels {
+			candidates = append(candidates, followedChannel{channel.Address, channel.FineAddress})
+		}
+		for _, channel := range candidates {
+			if !inUniverse(channel.coarse) {
+				channel = followedChannel{channel.fine, -1}
+			} else if !inUniverse(channel.fine) {
+				channel.fine = -1
+			}
+			if inUniverse(channel.coarse) {
+				channels = append(channels, channel)
+			}
+		}
+	}
+	return channels
+}
+
+// followedAddresses returns the addresses of the followed channels, coarse and fine bytes alike.
+func (a *App) followedAddresses(uni uint16) []int {
+	addresses := make([]int, 0)
+	for _, channel := range a.followedChannels(uni) {
+		addresses = append(addresses, channel.coarse)
+		if channel.fine >= 0 {
+			addresses = append(addresses, channel.fine)
+		}
+	}
+	return addresses
+}
+
+// mergeUniverse returns the data to send on an output universe, merging the followed channels of
+// data with the console's data if the universe is configured to merge. A 16 bit channel is merged as
+// one value, so its coarse and fine bytes always come from the same source.
+func (a *App) mergeUniverse(uni uint16, data DMXData, now time.Time) DMXData {
+	config := a.sacnConfig.universeConfig(uni)
+	if !merging(config) {
+		return data
+	}
+
+	channels := a.followedChannels(uni)
+
+	output, exists := a.followedOutputs[uni]
+	if !exists {
+		output = &followedOutput{}
+		a.followedOutputs[uni] = output
+	}
+	for _, address := range a.followedAddresses(uni) {
+		if output.data[address] != data[address] || output.changed[address].IsZero() {
+			output.data[address] = data[address]
+			output.changed[address] = now
+		}
+	}
+
+	input := a.sacnInputs[config.InputUniverse]
+	merged := DMXData{}
+	if input != nil {
+		merged = input.data
+	}
+
+	for _, channel := range channels {
+		switch config.MergeMode {
+		case MergeModeHTP:
+			if channel.value(&data) > channel.value(&merged) {
+				channel.copyTo(&merged, &data)
+			}
+		case MergeModeLTP:
+			if input == nil || !channel.changed(&input.changed).After(channel.changed(&output.changed)) {
+				channel.copyTo(&merged, &data)
+			}
+		default:
+			channel.copyTo(&merged, &data)
+		}
+	}
+	return merged
+}
+
+// mergeInputs returns the input universes of all merging output universes.
+func (a *App) mergeInputs() map[uint16]bool {
+	inputs := make(map[uint16]bool)
+	for uni := range a.activeUniverses {
+		config := a.sacnConfig.universeConfig(uni)
+		if merging(config) {
+			inputs[config.InputUniverse] = true
+		}
+	}
+	return inputs
+}
+
+// ensureSACNReceiver listens on the input universes of all merging output universes, the monitored
+// universes, the sACN output universes to notice conflicting sources and the universe discovery
+// universe to find other sources.
+func (a *App) ensureSACNReceiver() error {
+	inputs := a.mergeInputs()
+	universes := a.sacnOutputUniverses()
+	universes[sacn.DISCOVERY_UNIVERSE] = true
+	for uni := range inputs {
+		universes[uni] = true
+	}
+	for uni := range a.monitor {
+		universes[uni] = true
+	}
+	for uni := range a.sacnInputs {
+		if !inputs[uni] {
+			delete(a.sacnInputs, uni)
+		}
+	}
+
+	now := time.Now()
+	if a.receiver == nil {
+		if now.Before(a.receiverRetryAt) {
+			return errors.New("waiting to create the sACN receiver again")
+		}
+		// A failure is logged once and retried on a backoff, the worker calls this on every tick
+		failed := func(err error) error {
+			if err.Error() != a.receiverError {
+				LogError("Failed to create sACN receiver, retrying every %s: %s", sacnReceiverRetryInterval, err.Error())
+				a.receiverError = err.Error()
+			}
+			a.receiverRetryAt = now.Add(sacnReceiverRetryInterval)
+			return err
+		}
+
+		itf, err := interfaceWithIP(a.sacnConfig.IpAddress)
+		if err != nil {
+			return failed(fmt.Errorf("no network interface for IP %s: %w", a.sacnConfig.IpAddress, err))
+		}
+
+		receiver, err := newSACNReceiver(itf)
+		if err != nil {
+			return failed(fmt.Errorf("interface %s: %w", itf.Name, err))
+		}
+		a.receiverError = ""
+		receiver.RegisterPacketCallback(packet.PacketTypeData, a.receiveSACNData)
+		receiver.RegisterPacketCallback(packet.PacketTypeDiscovery, a.receiveSACNDiscovery)
+		receiver.RegisterTerminationCallback(func(uni uint16) {
+			LogInfo("Lost sACN input on universe %d, holding its last data", uni)
+		})
+		receiver.Start()
+
+		LogInfo("Created sACN receiver on interface %s", itf.Name)
+		a.receiver = receiver
+		a.receiverUniverses = make(map[uint16]bool)
+	}
+
+	for uni := range a.receiverUniverses {
+		if !universes[uni] {
+			LogInfo("Leaving universe %d", uni)
+			a.receiver.LeaveUniverse(uni)
+			delete(a.receiverUniverses, uni)
+			delete(a.receivedSequences, uni)
+		}
+	}
+	if now.Before(a.receiverRetryAt) {
+		return nil // joining failed a moment ago
+	}
+	for uni := range universes {
+		if a.receiverUniverses[uni] {
+			continue
+		}
+		if !a.failedJoins[uni] {
+			LogInfo("Joining universe %d", uni)
+		}
+		if err := a.receiver.JoinUniverse(uni); err != nil {
+			if !a.failedJoins[uni] {
+				LogError("Failed to join universe %d, retrying every %s: %s", uni, sacnReceiverRetryInterval, err.Error())
+				a.failedJoins[uni] = true
+			}
+			a.receiverRetryAt = now.Add(sacnReceiverRetryInterval)
+			continue
+		}
+		delete(a.failedJoins, uni)
+		a.receiverUniverses[uni] = true
+	}
+
+	return nil
+}
+
+func (a *App) closeSACNReceiver() {
+	// The settings changed or the worker stopped, a new receiver is tried straight away
+	a.receiverRetryAt = time.Time{}
+	a.receiverError = ""
+	a.failedJoins = make(map[uint16]bool)
+	if a.receiver == nil {
+		return
+	}
+
+	// The inputs are kept, so a new receiver continues merging the last data without a gap
+	a.receiver.Stop()
+	a.receiver = nil
+	a.receiverUniverses = make(map[uint16]bool)
+	a.receivedSequences = make(map[uint16]map[[16]byte]uint8)
+}
+
+// inSequence returns false for a packet which is not newer than the last one accepted from its source
+// on its universe, which E1.31 section 6.7.2 says to discard. The receiver calls back on a new
+// goroutine for every packet, so packets sent in order can also arrive here out of order.
+func (a *App) inSequence(p *packet.DataPacket) bool {
+	sequences, exists := a.receivedSequences[p.Universe]
+	if !exists {
+		sequences = make(map[[16]byte]uint8)
+		a.receivedSequences[p.Universe] = sequences
+	}
+	last, seen := sequences[p.CID]
+	if diff := int8(p.Sequence - last); seen && diff <= 0 && diff > -20 {
+		return false
+	}
+	sequences[p.CID] = p.Sequence
+	return true
+}
+
+// receiveSACNData passes a packet to the monitor and, unless it is out of order, merges its data into
+// the input universe it is on. Called by the receiver.
+func (a *App) receiveSACNData(p packet.SACNPacket, source string) {
+	data, ok := p.(*packet.DataPacket)
+	if !ok {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.receiverUniverses[data.Universe] {
+		return // a universe we just left
+	}
+
+	now := time.Now()
+	inSequence := a.inSequence(data)
+	a.monitorPacket(data, source, now, inSequence)
+	if !inSequence {
+		return
+	}
+	a.checkSourceConflict(data, source, now)
+
+	if data.GetStartCode() != startCodeNull || data.CID == a.sacnCID || !a.mergeInputs()[data.Universe] {
+		return // not levels, our own output, or only monitored
+	}
+
+	input, exists := a.sacnInputs[data.Universe]
+	if !exists {
+		input = &sacnInput{}
+		a.sacnInputs[data.Universe] = input
+	}
+	if _, known := input.sources[data.CID]; !known {
+		LogInfo("Receiving sACN input on universe %d from %s (%s) with priority %d", data.Universe, data.GetSourceName(), source, data.Priority)
+	}
+	input.receive(data.CID, data.Priority, data.GetData(), now)
+	a.dmxChanged()
+}
+
+// newSACNReceiver creates a receiver, the library panics if it can not listen on the sACN port.
+func newSACNReceiver(itf *net.Interface) (receiver *sacn.Receiver, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return sacn.NewReceiver(itf), nil
+}
+
+// interfaceWithIP returns the network interface with the given IPv4 address.
+func interfaceWithIP(ip string) (*net.Interface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.String() == ip {
+				return &iface, nil
+			}
+		}
+	}
+	return nil, errors.New("no network interface has the address " + ip)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+func TestInSequence(t *testing.T) {
+	a := &App{receivedSequences: make(map[uint16]map[[16]byte]uint8)}
+	console, other := [16]byte{1}, [16]byte{2}
+
+	tests := []struct {
+		name     string
+		universe uint16
+		cid      [16]byte
+		sequence uint8
+		want     bool
+	}{
+		{"first packet", 1, console, 100, true},
+		{"next", 1, console, 101, true},
+		{"duplicate", 1, console, 101, false},
+		{"late", 1, console, 99, false},
+		{"skipped ahead", 1, console, 110, true},
+		{"other source", 1, other, 5, true},
+		{"other universe", 2, console, 5, true},
+		{"wrapped around", 1, console, 2, true},
+		{"late across the wrap", 1, console, 250, false},
+		{"restarted source", 1, console, 200, true}, // more than 20 behind is a new stream
+	}
+	for _, test := range tests {
+		p := &packet.DataPacket{}
+		p.Universe, p.CID, p.Sequence = test.universe, test.cid, test.sequence
+		if got := a.inSequence(p); got != test.want {
+			t.Errorf("%s: inSequence(%d) = %v, want %v", test.name, test.sequence, got, test.want)
+		}
+	}
+}
+
+// consoleInput stands in for the console sending data on an input universe, every channel changed at changed.
+func consoleInput(data DMXData, changed time.Time) *sacnInput {
+	input := &sacnInput{data: data}
+	for i := range input.changed {
+		input.changed[i] = changed
+	}
+	return input
+}
+
+func TestMergeUniverse(t *testing.T) {
+	const pan, tilt, zoom, dimmer, unfollowed = 0, 2, 5, 9, 10
+	fixture := Fixture{Id: "spot", Universe: 1, PanAddress: pan, FinePanAddress: pan + 1, TiltAddress: tilt, FineTiltAddress: -1,
+		Channels: []FixtureChannel{{Id: "zoom", Address: zoom, FineAddress: zoom + 1}, {Id: "dimmer", Address: dimmer, FineAddress: -1}}}
+	set16 := func(data *DMXData, address int, value int) {
+		data[address], data[address+1] = byte(value>>8), byte(value)
+	}
+	get16 := func(data DMXData, address int) int {
+		return int(data[address])<<8 | int(data[address+1])
+	}
+
+	var console DMXData
+	set16(&console, pan, 0x90FF)
+	console[tilt] = 0x40
+	set16(&console, zoom, 0x80FF)
+	console[dimmer] = 0x20
+	console[unfollowed] = 0x77
+
+	tests := []struct {
+		mode                          string
+		pan, tilt, zoom, dimmer, rest int
+	}{
+		{MergeModeOff, 0x8001, 0x30, 0x8100, 0x10, 0},
+		{MergeModeOverride, 0x8001, 0x30, 0x8100, 0x10, 0x77},
+		// The higher 16 bit value wins whole, merging the bytes on their own would send zoom 0x81FF
+		{MergeModeHTP, 0x90FF, 0x40, 0x8100, 0x20, 0x77},
+		// Only the fine byte of pan changed after the console, pan still comes from Följe whole
+		{MergeModeLTP, 0x8001, 0x40, 0x80FF, 0x20, 0x77},
+	}
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			a := &App{
+				sacnConfig:      &SACNConfig{Universes: []SACNUniverseConfig{{Universe: 1, InputUniverse: 2, MergeMode: test.mode}}},
+				fixtures:        map[string]Fixture{"spot": fixture},
+				followedOutputs: make(map[uint16]*followedOutput),
+				sacnInputs:      make(map[uint16]*sacnInput),
+			}
+			start := time.Unix(100, 0)
+
+			var data DMXData
+			set16(&data, pan, 0x8000)
+			data[tilt] = 0x30
+			set16(&data, zoom, 0x8100)
+			data[dimmer] = 0x10
+			a.mergeUniverse(1, data, start)
+
+			a.sacnInputs[2] = consoleInput(console, start.Add(time.Second))
+
+			set16(&data, pan, 0x8001)
+			merged := a.mergeUniverse(1, data, start.Add(2*time.Second))
+
+			if got := get16(merged, pan); got != test.pan {
+				t.Errorf("pan %#x, want %#x", got, test.pan)
+			}
+			if got := int(merged[tilt]); got != test.tilt {
+				t.Errorf("tilt %#x, want %#x", got, test.tilt)
+			}
+			if got := get16(merged, zoom); got != test.zoom {
+				t.Errorf("zoom %#x, want %#x", got, test.zoom)
+			}
+			if got := int(merged[dimmer]); got != test.dimmer {
+				t.Errorf("dimmer %#x, want %#x", got, test.dimmer)
+			}
+			if got := int(merged[unfollowed]); got != test.rest {
+				t.Errorf("unfollowed channel %#x, want %#x", got, test.rest)
+			}
+		})
+	}
+}
+
+func TestSACNInputMergesSources(t *testing.T) {
+	console, backup, desk := [16]byte{1}, [16]byte{2}, [16]byte{3}
+	type packet struct {
+		cid      [16]byte
+		priority uint8
+		values   []byte
+		after    time.Duration
+	}
+	tests := []struct {
+		name    string
+		packets []packet
+		want    []byte
+	}{
+		{"one source", []packet{{console, 100, []byte{10, 20}, 0}}, []byte{10, 20}},
+		{"highest value of equal priorities", []packet{
+			{console, 100, []byte{10, 20}, 0},
+			{desk, 100, []byte{30, 5}, 0},
+		}, []byte{30, 20}},
+		{"highest priority only", []packet{
+			{console, 100, []byte{10, 20}, 0},
+			{backup, 150, []byte{1, 2}, 0},
+			{console, 100, []byte{10, 20}, time.Second},
+		}, []byte{1, 2}},
+		{"lower priority takes over after timeout", []packet{
+			{backup, 150, []byte{1, 2}, 0},
+			{console, 100, []byte{10, 20}, 0},
+			{console, 100, []byte{10, 20}, sacnInputTimeout + time.Second},
+		}, []byte{10, 20}},
+		{"lower priority source still sending hands back", []packet{
+			{backup, 150, []byte{1, 2}, 0},
+			{console, 100, []byte{10, 20}, 0},
+			{backup, 150, []byte{1, 2}, time.Second},
+		}, []byte{1, 2}},
+		{"unset channels are zero", []packet{
+			{console, 100, []byte{10, 20, 30}, 0},
+			{console, 100, []byte{10}, time.Second},
+		}, []byte{10, 0, 0}},
+	}
+	for _, test := range tests {
+		input := &sacnInput{}
+		now := time.Unix(100, 0)
+		for _, p := range test.packets {
+			now = now.Add(p.after)
+			input.receive(p.cid, p.priority, p.values, now)
+		}
+		if got := input.data[:len(test.want)]; string(got) != string(test.want) {
+			t.Errorf("%s: merged %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSACNInputTracksChanges(t *testing.T) {
+	input := &sacnInput{}
+	start := time.Unix(100, 0)
+	input.receive([16]byte{1}, 100, []byte{10, 20}, start)
+	input.receive([16]byte{1}, 100, []byte{10, 25}, start.Add(time.Second))
+
+	if !input.changed[0].Equal(start) {
+		t.Errorf("unchanged channel changed at %v, want %v", input.changed[0], start)
+	}
+	if !input.changed[1].Equal(start.Add(time.Second)) {
+		t.Errorf("changed channel changed at %v, want %v", input.changed[1], start.Add(time.Second))
+	}
+}
+
+func TestSACNReceiverRetriesOnBackoff(t *testing.T) {
+	a := &App{
+		sacnConfig:        &SACNConfig{IpAddress: "192.0.2.1"}, // TEST-NET-1, not on any interface
+		activeUniverses:   make(map[uint16]string),
+		receiverUniverses: make(map[uint16]bool),
+		failedJoins:       make(map[uint16]bool),
+		sacnInputs:        make(map[uint16]*sacnInput),
+	}
+
+	if err := a.ensureSACNReceiver(); err == nil {
+		t.Fatal("created a receiver without a network interface")
+	}
+	retryAt := a.receiverRetryAt
+	if time.Until(retryAt) <= 0 || a.receiverError == "" {
+		t.Fatalf("failure not recorded, retry at %v, error %q", retryAt, a.receiverError)
+	}
+
+	// Retrying right away does not try, and so does not move the retry or log again
+	a.ensureSACNReceiver()
+	if !a.receiverRetryAt.Equal(retryAt) {
+		t.Errorf("retried before %v", retryAt)
+	}
+
+	// A change of settings closes the receiver and tries again straight away
+	a.closeSACNReceiver()
+	if !a.receiverRetryAt.IsZero() || a.receiverError != "" {
+		t.Errorf("closing kept the failure, retry at %v, error %q", a.receiverRetryAt, a.receiverError)
+	}
+}
+
+func TestReleasesChannels(t *testing.T) {
+	tests := []struct {
+		name   string
+		config SACNUniverseConfig
+		want   bool
+	}{
+		{"Följe owns the universe", SACNUniverseConfig{Universe: 1}, false},
+		{"input without merging", SACNUniverseConfig{Universe: 1, InputUniverse: 2, MergeMode: MergeModeOff}, false},
+		{"merging", SACNUniverseConfig{Universe: 1, InputUniverse: 2, MergeMode: MergeModeLTP}, true},
+		{"per-address priority", SACNUniverseConfig{Universe: 1, PerAddressPriority: true}, true},
+		{"per-address priority with Art-Net", SACNUniverseConfig{Universe: 1, Protocol: ProtocolArtNet, PerAddressPriority: true}, false},
+		{"merging with Art-Net", SACNUniverseConfig{Universe: 1, Protocol: ProtocolArtNet, InputUniverse: 2, MergeMode: MergeModeHTP}, true},
+	}
+	for _, test := range tests {
+		if got := test.config.releasesChannels(); got != test.want {
+			t.Errorf("%s: releasesChannels() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"math"
+
+	"github.com/fogleman/delaunay"
+)
+
+// TriangleMesh is a Delaunay triangulation of calibration points together with a grid of buckets
+// listing the triangles overlapping each cell, so locating a point only tests a few triangles.
+// A mesh is immutable once built and shared by all fixtures calibrated for the same points.
+type TriangleMesh struct {
+	points []delaunay.Point
+	tri    *delaunay.Triangulation
+
+	minX, minY    float64
+	cellWidth     float64
+	cellHeight    float64
+	columns, rows int
+	cells         [][]int // triangle indices per cell, row major
+}
+
+func NewTriangleMesh(points []Point) (*TriangleMesh, error) {
+	if len(points) == 0 {
+		return nil, errors.New("points must have a non-zero length")
+	}
+
+	delaunayPoints := make([]delaunay.Point, len(points))
+	for i, point := range points {
+		delaunayPoints[i] = delaunay.Point{X: point.X, Y: point.Y}
+	}
+
+	tri, err := delaunay.Triangulate(delaunayPoints)
+	if err != nil {
+		return nil, err
+	}
+
+	mesh := &TriangleMesh{
+		points: delaunayPoints,
+		tri:    tri,
+	}
+	mesh.buildGrid()
+
+	return mesh, nil
+}
+
+// buildGrid buckets the triangles into a grid over the bounding box of the points, with
+// roughly one cell per triangle.
+func (mesh *TriangleMesh) buildGrid() {
+	numTriangles := len(mesh.tri.Triangles) / 3
+	if numTriangles == 0 {
+		return
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range mesh.points {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+
+	side := int(math.Ceil(math.Sqrt(float64(numTriangles))))
+	mesh.minX, mesh.minY = minX, minY
+	mesh.columns, mesh.rows = side, side
+	mesh.cellWidth = math.Max(maxX-minX, 1e-12) / float64(side)
+	mesh.cellHeight = math.Max(maxY-minY, 1e-12) / float64(side)
+	mesh.cells = make([][]int, side*side)
+
+	for t := 0; t < numTriangles; t++ {
+		a := mesh.points[mesh.tri.Triangles[3*t]]
+		b := mesh.points[mesh.tri.Triangles[3*t+1]]
+		c := mesh.points[mesh.tri.Triangles[3*t+2]]
+		col0, row0 := mesh.cellOf(math.Min(a.X, math.Min(b.X, c.X)), math.Min(a.Y, math.Min(b.Y, c.Y)))
+		col1, row1 := mesh.cellOf(math.Max(a.X, math.Max(b.X, c.X)), math.Max(a.Y, math.Max(b.Y, c.Y)))
+		for row := row0; row <= row1; row++ {
+			for col := col0; col <= col1; col++ {
+				mesh.cells[row*mesh.columns+col] = append(mesh.cells[row*mesh.columns+col], t)
+			}
+		}
+	}
+}
+
+// cellOf returns the grid cell containing (x, y), clamped to the grid.
+func (mesh *TriangleMesh) cellOf(x, y float64) (int, int) {
+	col := int((x - mesh.minX) / mesh.cellWidth)
+	row := int((y - mesh.minY) / mesh.cellHeight)
+	return max(0, min(mesh.columns-1, col)), max(0, min(mesh.rows-1, row))
+}
+
+// Locate returns the index of the triangle containing p.
+func (mesh *TriangleMesh) Locate(p delaunay.Point) (int, error) {
+	if len(mesh.cells) == 0 {
+		return -1, errors.New("no triangles in the triangulation")
+	}
+
+	// Allow for rounding, points on the hull edge may be a hair outside the bounding box
+	const epsilon = 1e-9
+	maxX := mesh.minX + mesh.cellWidth*float64(mesh.columns)
+	maxY := mesh.minY + mesh.cellHeight*float64(mesh.rows)
+	if p.X < mesh.minX-epsilon || p.Y < mesh.minY-epsilon || p.X > maxX+epsilon || p.Y > maxY+epsilon {
+		return -1, errors.New("point is outside the convex hull")
+	}
+
+	col, row := mesh.cellOf(p.X, p.Y)
+	for _, t := range mesh.cells[row*mesh.columns+col] {
+		a := mesh.points[mesh.tri.Triangles[3*t]]
+		b := mesh.points[mesh.tri.Triangles[3*t+1]]
+		c := mesh.points[mesh.tri.Triangles[3*t+2]]
+		if pointInTriangle(a, b, c, p) {
+			return t, nil
+		}
+	}
+
+	return -1, errors.New("point is outside the convex hull")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/fogleman/delaunay"
+)
+
+// randomCalibration returns n calibration points spread over the video, as clicked by hand.
+func randomCalibration(n int, random *rand.Rand) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{X: random.Float64(), Y: random.Float64()}
+	}
+	return points
+}
+
+// locateLinear is the scan over all triangles the grid replaced, kept as the reference.
+func locateLinear(mesh *TriangleMesh, p delaunay.Point) int {
+	for t := 0; t < len(mesh.tri.Triangles)/3; t++ {
+		a := mesh.points[mesh.tri.Triangles[3*t]]
+		b := mesh.points[mesh.tri.Triangles[3*t+1]]
+		c := mesh.points[mesh.tri.Triangles[3*t+2]]
+		if pointInTriangle(a, b, c, p) {
+			return t
+		}
+	}
+	return -1
+}
+
+func TestLocateAgreesWithLinearScan(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for _, n := range []int{3, 4, 10, 50, 200} {
+		mesh, err := NewTriangleMesh(randomCalibration(n, random))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		queries := append([]delaunay.Point(nil), mesh.points...) // on the vertices
+		for i := 0; i < 2000; i++ {
+			queries = append(queries, delaunay.Point{X: random.Float64()*1.2 - 0.1, Y: random.Float64()*1.2 - 0.1})
+		}
+		for _, q := range queries {
+			want := locateLinear(mesh, q)
+			got, err := mesh.Locate(q)
+			if (err == nil) != (want >= 0) {
+				t.Fatalf("%d points: Locate(%v) = %d, %v, linear scan found %d", n, q, got, err, want)
+			}
+			if err != nil {
+				continue
+			}
+			// On a shared edge both triangles are right, so check containment rather than the index
+			a := mesh.points[mesh.tri.Triangles[3*got]]
+			b := mesh.points[mesh.tri.Triangles[3*got+1]]
+			c := mesh.points[mesh.tri.Triangles[3*got+2]]
+			if !pointInTriangle(a, b, c, q) {
+				t.Fatalf("%d points: Locate(%v) = %d, which does not contain the point", n, q, got)
+			}
+		}
+	}
+}
+
+func TestLocateOutsideHull(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0.2, Y: 0.2}, {X: 0.8, Y: 0.2}, {X: 0.5, Y: 0.8}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Inside the bounding box but outside the triangle, and outside the bounding box
+	for _, q := range []delaunay.Point{{X: 0.25, Y: 0.75}, {X: 0.9, Y: 0.5}, {X: 0.5, Y: 0.1}} {
+		if triangle, err := mesh.Locate(q); err == nil {
+			t.Errorf("Locate(%v) = %d, want outside the hull", q, triangle)
+		}
+	}
+}
+
+// A show calibrates tens of points, a densely calibrated stage a few hundred.
+func BenchmarkLocate(b *testing.B) {
+	for _, n := range []int{20, 100, 500} {
+		random := rand.New(rand.NewSource(1))
+		mesh, err := NewTriangleMesh(randomCalibration(n, random))
+		if err != nil {
+			b.Fatal(err)
+		}
+		queries := make([]delaunay.Point, 1024)
+		for i := range queries {
+			queries[i] = delaunay.Point{X: random.Float64(), Y: random.Float64()}
+		}
+
+		b.Run(fmt.Sprintf("linear/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				locateLinear(mesh, queries[i%len(queries)])
+			}
+		})
+		b.Run(fmt.Sprintf("grid/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				mesh.Locate(queries[i%len(queries)])
+			}
+		})
+	}
+}
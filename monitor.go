@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"gitlab.com/patopest/go-sacn"
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// monitorForgetAfter is how long a source which stopped sending is still listed by the monitor.
+const monitorForgetAfter = 10 * time.Second
+
+// monitoredSource is what the monitor has received from one source on a universe.
+type monitoredSource struct {
+	name               string
+	ip                 string
+	priority           uint8
+	perAddressPriority time.Time // when per-address priority was last received
+	data               DMXData
+	sequenceErrors     int // packets dropped by the sequence check, see inSequence
+	lastSeen           time.Time
+
+	packets   int // packets since rateStart
+	rateStart time.Time
+	rate      float64 // packets per second over the last full second
+}
+
+// monitorPacket records a packet received on a monitored universe. The packet is counted as out of
+// order if the sequence check dropped it, which also counts packets the receiver reordered, as it calls
+// back on a new goroutine for every packet. Dropped packets do not update the values shown.
+func (a *App) monitorPacket(p *packet.DataPacket, ip string, now time.Time, inSequence bool) {
+	sources, exists := a.monitor[p.Universe]
+	if !exists {
+		return
+	}
+
+	source, exists := sources[p.CID]
+	if !exists {
+		LogInfo("sACN monitor: new source %s (%s) on universe %d", p.GetSourceName(), ip, p.Universe)
+		source = &monitoredSource{rateStart: now}
+		sources[p.CID] = source
+	}
+
+	source.name = p.GetSourceName()
+	source.ip = ip
+	source.lastSeen = now
+	if elapsed := now.Sub(source.rateStart); elapsed >= time.Second {
+		source.rate = float64(source.packets) / elapsed.Seconds()
+		source.packets = 0
+		source.rateStart = now
+	}
+	source.packets++
+	if !inSequence {
+		source.sequenceErrors++
+		return
+	}
+
+	switch p.GetStartCode() {
+	case startCodeNull:
+		source.priority = p.Priority
+		copy(source.data[:], p.GetData())
+	case startCodePerAddressPriority:
+		source.perAddressPriority = now
+	}
+}
+
+// SetMonitoredUniverses sets the universes the sACN monitor listens to.
+func (a *App) SetMonitoredUniverses(universes []uint16) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	monitor := make(map[uint16]map[[16]byte]*monitoredSource, len(universes))
+	for _, uni := range universes {
+		if sources, exists := a.monitor[uni]; exists {
+			monitor[uni] = sources
+		} else {
+			monitor[uni] = make(map[[16]byte]*monitoredSource)
+		}
+	}
+	LogInfo("SetMonitoredUniverses: %v", universes)
+	a.monitor = monitor
+	a.ensureSACNReceiver()
+}
+
+// GetSACNMonitor returns the sources the monitor has received on every monitored universe.
+func (a *App) GetSACNMonitor() []MonitoredUniverse {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	universes := make([]MonitoredUniverse, 0, len(a.monitor))
+	for uni, sources := range a.monitor {
+		universe := MonitoredUniverse{Universe: uni, Sources: make([]MonitoredSource, 0, len(sources))}
+		for cid, source := range sources {
+			age := now.Sub(source.lastSeen)
+			if age > monitorForgetAfter {
+				delete(sources, cid)
+				continue
+			}
+
+			rate := source.rate
+			if now.Sub(source.rateStart) > time.Second {
+				// Nothing received for a while, count the silence in
+				rate = float64(source.packets) / now.Sub(source.rateStart).Seconds()
+			}
+			universe.Sources = append(universe.Sources, MonitoredSource{
+				CID:                hex.EncodeToString(cid[:]),
+				Name:               source.name,
+				Ip:                 source.ip,
+				Own:                cid == a.sacnCID,
+				Active:             age < sacn.NETWORK_DATA_LOSS_TIMEOUT*time.Millisecond,
+				Priority:           source.priority,
+				PerAddressPriority: now.Sub(source.perAddressPriority) < sacn.NETWORK_DATA_LOSS_TIMEOUT*time.Millisecond,
+				PacketsPerSecond:   rate,
+				SequenceErrors:     source.sequenceErrors,
+				LastSeenMs:         age.Milliseconds(),
+				Data:               source.data,
+			})
+		}
+		sort.Slice(universe.Sources, func(i, j int) bool {
+			if universe.Sources[i].Priority != universe.Sources[j].Priority {
+				return universe.Sources[i].Priority > universe.Sources[j].Priority
+			}
+			return universe.Sources[i].Name < universe.Sources[j].Name
+		})
+		universes = append(universes, universe)
+	}
+	sort.Slice(universes, func(i, j int) bool { return universes[i].Universe < universes[j].Universe })
+	return universes
+}
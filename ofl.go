@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// oflFixture is the part of an Open Fixture Library fixture definition Följe uses,
+// see https://github.com/OpenLightingProject/open-fixture-library/blob/master/docs/fixture-format.md
+type oflFixture struct {
+	Name              string                `json:"name"`
+	ManufacturerKey   string                `json:"manufacturerKey"`
+	AvailableChannels map[string]oflChannel `json:"availableChannels"`
+	Modes             []oflMode             `json:"modes"`
+}
+
+type oflChannel struct {
+	FineChannelAliases []string        `json:"fineChannelAliases"`
+	DefaultValue       json.RawMessage `json:"defaultValue"`
+	Capability         *oflCapability  `json:"capability"`
+	Capabilities       []oflCapability `json:"capabilities"`
+}
+
+type oflCapability struct {
+	Type string `json:"type"`
+}
+
+type oflMode struct {
+	Name     string            `json:"name"`
+	Channels []json.RawMessage `json:"channels"` // channel key, null for an unused channel or a matrix insert block
+}
+
+func parseOFL(path string) (FixtureProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return FixtureProfile{}, err
+	}
+
+	var fixture oflFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return FixtureProfile{}, fmt.Errorf("not an Open Fixture Library file: %w", err)
+	}
+	if fixture.Name == "" || len(fixture.Modes) == 0 {
+		return FixtureProfile{}, errors.New("not an Open Fixture Library file: missing name or modes")
+	}
+
+	// Fine channels are listed in the modes by their alias, remember which channel and byte they are
+	fineAliases := make(map[string]string)
+	for key, channel := range fixture.AvailableChannels {
+		for _, alias := range channel.FineChannelAliases {
+			fineAliases[alias] = key
+		}
+	}
+
+	profile := FixtureProfile{
+		Name:         fixture.Name,
+		Manufacturer: oflManufacturerName(fixture.ManufacturerKey),
+		Modes:        make([]FixtureProfileMode, 0, len(fixture.Modes)),
+	}
+	for _, oflMode := range fixture.Modes {
+		mode := FixtureProfileMode{
+			Name:      oflMode.Name,
+			Footprint: len(oflMode.Channels),
+		}
+
+		channelIndex := make(map[string]int) // index in mode.Channels by channel key
+		for offset, raw := range oflMode.Channels {
+			var key string
+			if err := json.Unmarshal(raw, &key); err != nil || key == "" {
+				if string(raw) != "null" {
+					return FixtureProfile{}, fmt.Errorf("mode %s uses matrix channels, which are not supported", oflMode.Name)
+				}
+				continue
+			}
+
+			coarseKey := key
+			if alias, isFine := fineAliases[key]; isFine {
+				coarseKey = alias
+			}
+			index, exists := channelIndex[coarseKey]
+			if !exists {
+				channel := fixture.AvailableChannels[coarseKey]
+				index = len(mode.Channels)
+				channelIndex[coarseKey] = index
+				mode.Channels = append(mode.Channels, ProfileChannel{
+					Name:      coarseKey,
+					Attribute: channel.attribute(),
+					Offsets:   make([]int, 1+len(channel.FineChannelAliases)),
+				})
+				for i := range mode.Channels[index].Offsets {
+					mode.Channels[index].Offsets[i] = -1
+				}
+			}
+
+			byteIndex := 0
+			if coarseKey != key {
+				byteIndex = 1 + indexOf(fixture.AvailableChannels[coarseKey].FineChannelAliases, key)
+			}
+			mode.Channels[index].Offsets[byteIndex] = offset
+		}
+
+		// Drop fine bytes which are not used in this mode, then the default at the used resolution
+		for i, channel := range mode.Channels {
+			offsets := make([]int, 0, len(channel.Offsets))
+			for _, offset := range channel.Offsets {
+				if offset < 0 {
+					break
+				}
+				offsets = append(offsets, offset)
+			}
+			if len(offsets) == 0 {
+				return FixtureProfile{}, fmt.Errorf("mode %s uses a fine channel of %s without the channel itself", oflMode.Name, channel.Name)
+			}
+			mode.Channels[i].Offsets = offsets
+			mode.Channels[i].Default = fixture.AvailableChannels[channel.Name].defaultValue(len(offsets))
+		}
+
+		profile.Modes = append(profile.Modes, mode)
+	}
+
+	return profile, nil
+}
+
+// attribute maps the capability type of a channel to the attribute Följe drives.
+func (channel oflChannel) attribute() string {
+	capabilityType := ""
+	if channel.Capability != nil {
+		capabilityType = channel.Capability.Type
+	} else if len(channel.Capabilities) > 0 {
+		capabilityType = channel.Capabilities[0].Type
+	}
+
+	switch capabilityType {
+	case "Pan":
+		return ChannelAttributePan
+	case "Tilt":
+		return ChannelAttributeTilt
+	default:
+		return ""
+	}
+}
+
+// defaultValue returns the default of the channel scaled to the given number of bytes. The default is
+// a DMX value, which OFL gives in the highest resolution of the channel, or a percentage like "50%".
+func (channel oflChannel) defaultValue(bytes int) int {
+	if len(channel.DefaultValue) == 0 {
+		return 0
+	}
+	maxValue := math.Pow(256, float64(bytes)) - 1
+
+	var percentage string
+	if err := json.Unmarshal(channel.DefaultValue, &percentage); err == nil {
+		value, err := strconv.ParseFloat(strings.TrimSuffix(percentage, "%"), 64)
+		if err != nil {
+			return 0
+		}
+		return int(math.Round(value / 100 * maxValue))
+	}
+
+	var value float64
+	if err := json.Unmarshal(channel.DefaultValue, &value); err != nil {
+		return 0
+	}
+	if definedBytes := 1 + len(channel.FineChannelAliases); definedBytes > bytes {
+		value = math.Floor(value / math.Pow(256, float64(definedBytes-bytes)))
+	}
+	return int(math.Min(value, maxValue))
+}
+
+// oflManufacturerName turns a manufacturer key like "martin" or "robe-lighting" into a name.
+func oflManufacturerName(key string) string {
+	words := strings.Split(key, "-")
+	for i, word := range words {
+		if word != "" {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
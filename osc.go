@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+)
+
+// oscMessage is an OSC 1.0 message. Arguments are int32, int64, float32, float64, string, []byte
+// (blob), bool (T/F) or nil (N/I).
+type oscMessage struct {
+	address string
+	args    []any
+}
+
+// oscServer receives OSC messages over UDP.
+type oscServer struct {
+	conn    *net.UDPConn
+	ip      string
+	port    int
+	handler func(oscMessage)
+}
+
+func newOSCServer(ip string, port int, handler func(oscMessage)) (*oscServer, error) {
+	localIP := net.ParseIP(ip)
+	if localIP == nil {
+		return nil, fmt.Errorf("%s is not an IP address", ip)
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: localIP, Port: port})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &oscServer{conn: conn, ip: ip, port: port, handler: handler}
+	go s.receiveLoop()
+
+	LogInfo("Listening for OSC on %s port %d", ip, port)
+	return s, nil
+}
+
+func (s *oscServer) Close() {
+	LogInfo("Closing OSC server on port %d", s.port)
+	s.conn.Close()
+}
+
+func (s *oscServer) receiveLoop() {
+	buffer := make([]byte, 65536)
+	for {
+		n, source, err := s.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			LogDebug("Failed to receive OSC packet: %s", err.Error())
+			continue
+		}
+
+		messages, err := parseOSCPacket(buffer[:n])
+		if err != nil {
+			LogDebug("Invalid OSC packet from %s: %s", source, err.Error())
+			continue
+		}
+		for _, message := range messages {
+			s.handler(message)
+		}
+	}
+}
+
+// parseOSCPacket parses an OSC message or bundle, returning the messages of a bundle in order. Bundle
+// time tags are ignored, everything is handled when it arrives.
+func parseOSCPacket(p []byte) ([]oscMessage, error) {
+	if bytes.HasPrefix(p, []byte("#bundle\x00")) {
+		if len(p) < 16 {
+			return nil, errors.New("bundle too short")
+		}
+
+		var messages []oscMessage
+		for rest := p[16:]; len(rest) > 0; {
+			if len(rest) < 4 {
+				return nil, errors.New("truncated bundle element")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			if size > len(rest)-4 {
+				return nil, errors.New("bundle element larger than the bundle")
+			}
+			elementMessages, err := parseOSCPacket(rest[4 : 4+size])
+			if err != nil {
+				return nil, err
+			}
+			messages = append(messages, elementMessages...)
+			rest = rest[4+size:]
+		}
+		return messages, nil
+	}
+
+	message, err := parseOSCMessage(p)
+	if err != nil {
+		return nil, err
+	}
+	return []oscMessage{message}, nil
+}
+
+func parseOSCMessage(p []byte) (oscMessage, error) {
+	address, rest, err := readOSCString(p)
+	if err != nil {
+		return oscMessage{}, err
+	}
+	if !strings.HasPrefix(address, "/") {
+		return oscMessage{}, fmt.Errorf("invalid address %q", address)
+	}
+
+	message := oscMessage{address: address}
+	if len(rest) == 0 {
+		return message, nil // old implementations may leave out the type tags
+	}
+	tags, rest, err := readOSCString(rest)
+	if err != nil {
+		return oscMessage{}, err
+	}
+	if !strings.HasPrefix(tags, ",") {
+		return oscMessage{}, fmt.Errorf("invalid type tags %q", tags)
+	}
+
+	for _, tag := range tags[1:] {
+		var arg any
+		switch tag {
+		case 'i', 'f', 'r', 'c', 'm':
+			if len(rest) < 4 {
+				return oscMessage{}, errors.New("truncated argument")
+			}
+			bits := binary.BigEndian.Uint32(rest)
+			switch tag {
+			case 'f':
+				arg = math.Float32frombits(bits)
+			default:
+				arg = int32(bits)
+			}
+			rest = rest[4:]
+		case 'h', 'd', 't':
+			if len(rest) < 8 {
+				return oscMessage{}, errors.New("truncated argument")
+			}
+			bits := binary.BigEndian.Uint64(rest)
+			if tag == 'd' {
+				arg = math.Float64frombits(bits)
+			} else {
+				arg = int64(bits)
+			}
+			rest = rest[8:]
+		case 's', 'S':
+			arg, rest, err = readOSCString(rest)
+			if err != nil {
+				return oscMessage{}, err
+			}
+		case 'b':
+			if len(rest) < 4 {
+				return oscMessage{}, errors.New("truncated blob")
+			}
+			size := int(binary.BigEndian.Uint32(rest))
+			padded := 4 + (size+3)/4*4
+			if size > len(rest)-4 || padded > len(rest) {
+				return oscMessage{}, errors.New("truncated blob")
+			}
+			arg = rest[4 : 4+size]
+			rest = rest[padded:]
+		case 'T':
+			arg = true
+		case 'F':
+			arg = false
+		case 'N', 'I':
+			arg = nil
+		default:
+			return oscMessage{}, fmt.Errorf("unsupported type tag %q", tag)
+		}
+		message.args = append(message.args, arg)
+	}
+	return message, nil
+}
+
+// readOSCString reads a null terminated string padded to four bytes.
+func readOSCString(p []byte) (string, []byte, error) {
+	end := bytes.IndexByte(p, 0)
+	if end < 0 {
+		return "", nil, errors.New("unterminated string")
+	}
+	padded := min((end+4)/4*4, len(p))
+	return string(p[:end]), p[padded:], nil
+}
+
+// number returns argument i as a float64 and whether it is a float, false if it is not a number or
+// not finite.
+func (m oscMessage) number(i int) (value float64, isFloat bool, ok bool) {
+	if i >= len(m.args) {
+		return 0, false, false
+	}
+	switch arg := m.args[i].(type) {
+	case float32:
+		if math.IsNaN(float64(arg)) || math.IsInf(float64(arg), 0) {
+			return 0, false, false
+		}
+		return float64(arg), true, true
+	case float64:
+		if math.IsNaN(arg) || math.IsInf(arg, 0) {
+			return 0, false, false
+		}
+		return arg, true, true
+	case int32:
+		return float64(arg), false, true
+	case int64:
+		return float64(arg), false, true
+	case bool:
+		if arg {
+			return 1, false, true
+		}
+		return 0, false, true
+	}
+	return 0, false, false
+}
+
+// dmx16 returns argument i as a 16 bit DMX value, integers are taken as is and floats from 0 to 1 are
+// scaled, as faders on OSC tablets send floats.
+func (m oscMessage) dmx16(i int) (int, bool) {
+	value, isFloat, ok := m.number(i)
+	if !ok {
+		return 0, false
+	}
+	if isFloat {
+		value *= 65535
+	}
+	return int(math.Round(value)), true
+}
+
+// handleOSCMessage maps an OSC message onto the App. Called by the OSC server.
+//
+//	/folje/xy x y                            follow a position on the video, 0 to 1 on both axes
+//	/folje/target/<target>/xy x y            the same for the fixtures following another target
+//	/folje/lock [locked]                     lock (default) or unlock the mouse position
+//	/folje/fixture/<fixture>/pantilt pan tilt
+//	/folje/fixture/<fixture>/channel/<channel> value
+//	/folje/cue/<cue>                         fade to a cue
+//	/folje/cue/release                       follow again after a cue
+//
+// Fixtures, channels and cues are given by id or name, values are 0-65535 or floats from 0 to 1.
+func (a *App) handleOSCMessage(m oscMessage) {
+	parts := strings.Split(strings.TrimPrefix(m.address, "/"), "/")
+	if parts[0] != "folje" {
+		LogDebug("Ignoring OSC message %s", m.address)
+		return
+	}
+
+	var err error
+	switch {
+	case len(parts) == 2 && parts[1] == "xy", len(parts) == 4 && parts[1] == "target" && parts[3] == "xy":
+		target := DefaultTarget
+		if len(parts) == 4 {
+			target = parts[2]
+		}
+		x, _, okX := m.number(0)
+		y, _, okY := m.number(1)
+		if !okX || !okY {
+			err = errors.New("expected two numbers x and y")
+			break
+		}
+		err = a.remoteSetPosition(target, x, y)
+	case len(parts) == 2 && parts[1] == "lock":
+		locked := true
+		if value, _, ok := m.number(0); ok {
+			locked = value != 0
+		}
+		a.remoteLock(locked)
+	case len(parts) == 4 && parts[1] == "fixture" && parts[3] == "pantilt":
+		pan, okPan := m.dmx16(0)
+		tilt, okTilt := m.dmx16(1)
+		if !okPan || !okTilt {
+			err = errors.New("expected two numbers pan and tilt")
+			break
+		}
+		err = a.remoteSetPanTilt(parts[2], pan, tilt)
+	case len(parts) == 5 && parts[1] == "fixture" && parts[3] == "channel":
+		value, ok := m.dmx16(0)
+		if !ok {
+			err = errors.New("expected a number")
+			break
+		}
+		err = a.remoteSetChannel(parts[2], parts[4], value)
+	case len(parts) == 3 && parts[1] == "cue" && parts[2] == "release":
+		a.ReleaseCue()
+	case len(parts) == 3 && parts[1] == "cue":
+		err = a.remoteGoCue(parts[2])
+	default:
+		err = errors.New("unknown address")
+	}
+
+	if err != nil {
+		LogError("Invalid OSC message %s %v: %s", m.address, m.args, err.Error())
+	}
+}
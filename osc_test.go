@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// oscString encodes a null terminated OSC string padded to four bytes.
+func oscString(s string) []byte {
+	b := append([]byte(s), 0)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func oscInt32(v int32) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(v))
+}
+
+func oscFloat32(v float32) []byte {
+	return binary.BigEndian.AppendUint32(nil, math.Float32bits(v))
+}
+
+func oscFloat64(v float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(v))
+}
+
+func oscBundle(elements ...[]byte) []byte {
+	b := append(oscString("#bundle"), 0, 0, 0, 0, 0, 0, 0, 1) // time tag: immediately
+	for _, element := range elements {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(element)))
+		b = append(b, element...)
+	}
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func TestParseOSCPacket(t *testing.T) {
+	xy := concat(oscString("/folje/xy"), oscString(",ff"), oscFloat32(0.25), oscFloat32(0.75))
+	release := oscString("/folje/cue/release")
+
+	tests := []struct {
+		name   string
+		packet []byte
+		want   []oscMessage
+	}{
+		{"floats", xy, []oscMessage{{address: "/folje/xy", args: []any{float32(0.25), float32(0.75)}}}},
+		{"no arguments", concat(release, oscString(",")), []oscMessage{{address: "/folje/cue/release"}}},
+		{"no type tags", release, []oscMessage{{address: "/folje/cue/release"}}},
+		{"every type", concat(oscString("/a"), oscString(",ihdsbTFN"), oscInt32(-3),
+			binary.BigEndian.AppendUint64(nil, 1<<40), oscFloat64(0.5), oscString("cue"),
+			oscInt32(5), []byte{1, 2, 3, 4, 5, 0, 0, 0}), []oscMessage{{address: "/a",
+			args: []any{int32(-3), int64(1 << 40), 0.5, "cue", []byte{1, 2, 3, 4, 5}, true, false, nil}}}},
+		{"bundle", oscBundle(xy, release), []oscMessage{
+			{address: "/folje/xy", args: []any{float32(0.25), float32(0.75)}},
+			{address: "/folje/cue/release"},
+		}},
+		{"nested bundle", oscBundle(oscBundle(release), xy), []oscMessage{
+			{address: "/folje/cue/release"},
+			{address: "/folje/xy", args: []any{float32(0.25), float32(0.75)}},
+		}},
+		{"empty bundle", oscBundle(), nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseOSCPacket(test.packet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOSCPacketRejectsMalformed(t *testing.T) {
+	xy := concat(oscString("/folje/xy"), oscString(",ff"), oscFloat32(0.25), oscFloat32(0.75))
+	oversized := oscBundle(xy)
+	binary.BigEndian.PutUint32(oversized[16:], uint32(len(xy)+1))
+
+	tests := []struct {
+		name   string
+		packet []byte
+	}{
+		{"empty", nil},
+		{"unterminated address", []byte("/folje/xy")},
+		{"address without slash", oscString("folje/xy")},
+		{"type tags without comma", concat(oscString("/folje/xy"), oscString("ff"))},
+		{"unterminated type tags", concat(oscString("/folje/xy"), []byte(",ff"))},
+		{"truncated float", xy[:len(xy)-2]},
+		{"missing argument", xy[:len(xy)-4]},
+		{"truncated double", concat(oscString("/a"), oscString(",d"), oscInt32(0))},
+		{"unterminated string argument", concat(oscString("/a"), oscString(",s"), []byte("cue"))},
+		{"truncated blob size", concat(oscString("/a"), oscString(",b"), []byte{0, 0})},
+		{"blob larger than the message", concat(oscString("/a"), oscString(",b"), oscInt32(8), []byte{1, 2, 3, 4})},
+		{"negative blob size", concat(oscString("/a"), oscString(",b"), oscInt32(-4), []byte{1, 2, 3, 4})},
+		{"unsupported type tag", concat(oscString("/a"), oscString(",x"), oscInt32(0))},
+		{"truncated bundle header", oscBundle()[:12]},
+		{"truncated bundle element size", append(oscBundle(), 0, 0)},
+		{"bundle element larger than the bundle", oversized},
+		{"malformed bundle element", oscBundle(xy[:len(xy)-2])},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if messages, err := parseOSCPacket(test.packet); err == nil {
+				t.Errorf("parsed %#v", messages)
+			}
+		})
+	}
+}
+
+func TestOSCMessageNumber(t *testing.T) {
+	tests := []struct {
+		arg     any
+		value   float64
+		isFloat bool
+		ok      bool
+	}{
+		{int32(-7), -7, false, true},
+		{int64(1 << 40), 1 << 40, false, true},
+		{float32(0.5), 0.5, true, true},
+		{0.25, 0.25, true, true},
+		{true, 1, false, true},
+		{false, 0, false, true},
+		{float32(math.NaN()), 0, false, false},
+		{float32(math.Inf(1)), 0, false, false},
+		{math.NaN(), 0, false, false},
+		{math.Inf(-1), 0, false, false},
+		{"0.5", 0, false, false},
+		{nil, 0, false, false},
+	}
+	for _, test := range tests {
+		value, isFloat, ok := oscMessage{args: []any{test.arg}}.number(0)
+		if value != test.value || isFloat != test.isFloat || ok != test.ok {
+			t.Errorf("number(%#v) = %v, %v, %v, want %v, %v, %v", test.arg, value, isFloat, ok, test.value, test.isFloat, test.ok)
+		}
+	}
+	if _, _, ok := (oscMessage{}).number(0); ok {
+		t.Error("number of a missing argument is ok")
+	}
+}
+
+// Positions which are not numbers and targets no fixture follows must not reach the target positions.
+func TestHandleOSCPosition(t *testing.T) {
+	a := &App{
+		fixtures:        map[string]Fixture{"spot": {Id: "spot"}, "wash": {Id: "wash", Target: "singer"}},
+		targetPositions: make(map[string]Point),
+	}
+	messages := []oscMessage{
+		{address: "/folje/xy", args: []any{float32(math.NaN()), float32(0.5)}},
+		{address: "/folje/xy", args: []any{0.5, math.Inf(1)}},
+		{address: "/folje/target/nobody/xy", args: []any{float32(0.5), float32(0.5)}},
+		{address: "/folje/target/singer/xy", args: []any{float32(0.25), float32(1.5)}},
+		{address: "/folje/xy", args: []any{int32(0), int32(1)}},
+	}
+	for _, m := range messages {
+		a.handleOSCMessage(m)
+	}
+
+	want := map[string]Point{"singer": {X: 0.25, Y: 1}, DefaultTarget: {X: 0, Y: 1}}
+	if !reflect.DeepEqual(a.targetPositions, want) {
+		t.Errorf("target positions %v, want %v", a.targetPositions, want)
+	}
+}
+
+func TestHandleOSCCueRelease(t *testing.T) {
+	a := &App{
+		cues:            []Cue{{Id: "c1", Name: "Intro"}},
+		cue:             &runningCue{cue: Cue{Id: "c1", Name: "Intro"}},
+		targetPositions: make(map[string]Point),
+	}
+	a.handleOSCMessage(oscMessage{address: "/folje/cue/release"})
+	if a.cue != nil {
+		t.Error("/folje/cue/release did not release the cue")
+	}
+}
+
+func TestRemoteListenAddress(t *testing.T) {
+	free, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := free.LocalAddr().(*net.UDPAddr).Port
+	free.Close()
+
+	tests := []struct {
+		name, sacnIP, listenAddress, want string
+		valid                             bool
+	}{
+		{"sACN IP address", "127.0.0.1", "", "127.0.0.1", true},
+		{"no sACN IP address yet", "", "", "", true},
+		{"this computer only", "192.0.2.1", "127.0.0.1", "127.0.0.1", true},
+		{"every network", "192.0.2.1", "0.0.0.0", "0.0.0.0", true},
+		{"not an address", "127.0.0.1", "localhost", "", false},
+	}
+	for _, test := range tests {
+		a := &App{
+			sacnConfig:   &SACNConfig{IpAddress: test.sacnIP},
+			remoteConfig: RemoteConfig{OscEnabled: true, OscPort: port, ListenAddress: test.listenAddress},
+		}
+		closing, err := a.applyRemoteConfig()
+		closeRemoteServers(closing)
+		if (err == nil) != test.valid {
+			t.Errorf("%s: applyRemoteConfig() = %v, want valid %v", test.name, err, test.valid)
+		}
+		switch {
+		case test.want == "" && a.osc != nil:
+			t.Errorf("%s: listening on %s, want no server", test.name, a.osc.ip)
+		case test.want != "" && (a.osc == nil || a.osc.ip != test.want):
+			t.Errorf("%s: server %+v, want one listening on %s", test.name, a.osc, test.want)
+		}
+		a.closeRemoteControl()
+	}
+}
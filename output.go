@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Protocols an output universe can be sent with.
+const (
+	ProtocolSACN   = "sacn"
+	ProtocolArtNet = "artnet"
+)
+
+// Stop modes decide what receivers are left with when Följe stops.
+const (
+	StopModeHold    = "hold"    // stop sending, receivers hold the last look until their data loss behaviour kicks in
+	StopModeHome    = "home"    // send the home position of the fixtures, then stop like hold
+	StopModeRelease = "release" // terminate the streams so receivers release the channels to other sources straight away
+)
+
+// startCodeNull is the start code of packets with DMX levels.
+const startCodeNull = 0x00
+
+// homeFrames is how many frames of the home position are sent when stopping, so a lost packet does not matter.
+const homeFrames = changeRepeats + 1
+
+// DMXOutput sends universes on the network with a DMX over IP protocol.
+type DMXOutput interface {
+	StartUniverse(uni uint16) error
+	StopUniverse(uni uint16)
+	// SetRouting sets whether a universe is multicast (broadcast for Art-Net) and its unicast destinations.
+	SetRouting(uni uint16, multicast bool, destinations []string)
+	// Send sends data with the given DMX start code on a universe. Protocols without priority ignore
+	// priority and protocols only carrying levels ignore other start codes.
+	Send(uni uint16, startCode byte, data DMXData, priority uint8) error
+	// Terminate tells receivers the universe stops so they do not wait for it to time out, for
+	// protocols that can.
+	Terminate(uni uint16)
+	Close()
+}
+
+// protocol returns the protocol of the universe, sACN if none is set.
+func (config SACNUniverseConfig) protocol() string {
+	if config.Protocol == "" {
+		return ProtocolSACN
+	}
+	return config.Protocol
+}
+
+func (a *App) ensureOutput(protocol string) (DMXOutput, error) {
+	if output, exists := a.outputs[protocol]; exists {
+		return output, nil
+	}
+
+	var output DMXOutput
+	var err error
+	switch protocol {
+	case ProtocolSACN:
+		// Keeping the CID and continuing the sequence numbers makes receivers see a new sender as the
+		// same source, so it takes over without a gap
+		var sacnOutput *sacnOutput
+		sacnOutput, err = newSACNOutput(a.sacnConfig.IpAddress, a.sacnCID, a.sacnSequences)
+		if err == nil {
+			a.sacnCID = sacnOutput.cid
+			output = sacnOutput
+		}
+	case ProtocolArtNet:
+		output, err = newArtNetOutput(a.sacnConfig.IpAddress)
+	default:
+		err = fmt.Errorf("unknown protocol %s", protocol)
+	}
+	if err != nil {
+		LogError("Failed to create %s output on IP %s: %s", protocol, a.sacnConfig.IpAddress, err.Error())
+		return nil, err
+	}
+
+	a.outputs[protocol] = output
+	return output, nil
+}
+
+func (a *App) closeOutputs() {
+	for protocol, output := range a.outputs {
+		LogInfo("Closing %s output", protocol)
+		if sacnOutput, ok := output.(*sacnOutput); ok {
+			a.sacnSequences = sacnOutput.sequences()
+		}
+		output.Close()
+	}
+
+	a.outputs = make(map[string]DMXOutput)
+	a.activeUniverses = make(map[uint16]string)
+	a.sentPriorities = make(map[uint16]*sentPriorities)
+	a.transmitted = make(map[uint16]*transmittedUniverse)
+}
+
+// stopOutputs leaves the receivers as set by the stop mode and closes the outputs.
+func (a *App) stopOutputs() {
+	switch a.sacnConfig.StopMode {
+	case StopModeHome:
+		LogInfo("Stopping outputs, sending home position")
+		a.stoppingOutputs = true
+		a.sendHome()
+		a.stoppingOutputs = false
+	case StopModeRelease:
+		LogInfo("Stopping outputs, releasing universes")
+		for uni, protocol := range a.activeUniverses {
+			a.outputs[protocol].Terminate(uni)
+		}
+	default:
+		LogInfo("Stopping outputs, receivers hold the last look")
+	}
+
+	a.closeOutputs()
+}
+
+// sendHome moves the fixtures which are not released to their home position with the profile and
+// channel defaults. Called with a.mu held, which is released while waiting between frames, so the
+// outputs must not be replaced while stoppingOutputs is set.
+func (a *App) sendHome() {
+	a.motionFilters = make(map[string]*motionFilterState)
+	a.writeProfileDefaults()
+	for _, fixture := range a.fixtures {
+		if a.fixtureControls[fixture.Id].mode() == FixtureModeRelease {
+			continue
+		}
+		a.setPanTiltForFixture(fixture.Id, fixture.HomePan, fixture.HomeTilt)
+	}
+
+	// Every frame sends the same data, so handlers running between frames do not move the fixtures
+	now := time.Now()
+	frames := make(map[uint16]DMXData, len(a.activeUniverses))
+	for uni := range a.activeUniverses {
+		frames[uni] = a.mergeUniverse(uni, a.universeDMXData[uni], now)
+	}
+	universes := maps.Clone(a.activeUniverses)
+	outputs := maps.Clone(a.outputs)
+
+	interval := a.sacnConfig.sendInterval()
+	for frame := range homeFrames {
+		if frame > 0 {
+			a.mu.Unlock()
+			time.Sleep(interval)
+			a.mu.Lock()
+		}
+		for uni, protocol := range universes {
+			err := outputs[protocol].Send(uni, startCodeNull, frames[uni], a.sacnConfig.universeConfig(uni).priority())
+			a.recordSend(uni, protocol, err)
+		}
+	}
+}
+
+// ensureUniverses sends the universes fixtures are patched to with the protocol configured for them,
+// and stops sending the others.
+func (a *App) ensureUniverses() {
+	for uni, protocol := range a.activeUniverses {
+		inUse := false
+		for _, fixture := range a.fixtures {
+			if uni == fixture.Universe {
+				inUse = true
+				break
+			}
+		}
+
+		if inUse && protocol == a.sacnConfig.universeConfig(uni).protocol() {
+			continue
+		}
+
+		a.deactivateUniverse(uni)
+	}
+
+	for _, fixture := range a.fixtures {
+		a.activateUniverse(fixture.Universe)
+	}
+}
+
+func (a *App) activateUniverse(uni uint16) {
+	if _, active := a.activeUniverses[uni]; active {
+		return
+	}
+
+	protocol := a.sacnConfig.universeConfig(uni).protocol()
+	output, err := a.ensureOutput(protocol)
+	if err != nil {
+		return
+	}
+
+	LogInfo("Activating universe %d (%s)", uni, protocol)
+	if err := output.StartUniverse(uni); err != nil {
+		LogError("Failed to start universe %d: %s", uni, err.Error())
+		return
+	}
+	a.activeUniverses[uni] = protocol
+	a.applyUniverseRouting(uni)
+}
+
+func (a *App) deactivateUniverse(uni uint16) {
+	protocol, active := a.activeUniverses[uni]
+	if !active {
+		return
+	}
+
+	LogInfo("Deactivating universe %d (%s)", uni, protocol)
+	if output, exists := a.outputs[protocol]; exists {
+		output.Terminate(uni)
+		output.StopUniverse(uni)
+	}
+	delete(a.activeUniverses, uni)
+	delete(a.sentPriorities, uni)
+	delete(a.transmitted, uni)
+}
+
+// applyUniverseRouting sets where an active universe is sent from the current config.
+func (a *App) applyUniverseRouting(uni uint16) {
+	output, exists := a.outputs[a.activeUniverses[uni]]
+	if !exists {
+		return
+	}
+
+	multicast, destinations := a.sacnConfig.routing(uni)
+	output.SetRouting(uni, multicast, destinations)
+}
+
+// rerouteUniverses applies the routing of universes whose routing changed from oldConfig and still
+// use the same protocol, the others keep sending undisturbed.
+func (a *App) rerouteUniverses(oldConfig *SACNConfig) {
+	for uni, protocol := range a.activeUniverses {
+		if oldConfig.universeConfig(uni).protocol() != protocol {
+			continue // restarted with the new protocol and routing
+		}
+
+		oldMulticast, oldDestinations := oldConfig.routing(uni)
+		multicast, destinations := a.sacnConfig.routing(uni)
+		if oldMulticast == multicast && slices.Equal(oldDestinations, destinations) {
+			continue
+		}
+		LogInfo("Rerouting universe %d: Multicast=%v, Destinations=%v", uni, multicast, destinations)
+		a.applyUniverseRouting(uni)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// PoseSample is a calibration sample of a fixture: the pan/tilt it was aimed with at a known floor position.
+type PoseSample struct {
+	Id    string // calibration point id
+	Floor Point
+	Pan   float64
+	Tilt  float64
+}
+
+// FixturePose describes where a fixture hangs and how its pan/tilt values map to beam angles.
+// Floor coordinates are in the same unit as the calibration point floor positions, Z is the height above the floor.
+type FixturePose struct {
+	X          float64
+	Y          float64
+	Z          float64
+	Yaw        float64 // direction the pan angle is measured from, in radians
+	Roll       float64 // mounting tilt around the Yaw direction, in radians
+	Pitch      float64 // mounting tilt across the Yaw direction, in radians
+	PanOffset  float64 // pan value when aiming along Yaw
+	PanScale   float64 // pan value per radian
+	TiltOffset float64 // tilt value when aiming straight down
+	TiltScale  float64 // tilt value per radian
+}
+
+// angles returns the pan angle relative to Yaw and the tilt angle from the fixture's down axis needed to hit target.
+func (pose FixturePose) angles(target Point) (float64, float64) {
+	// Direction to the target, rotated into the fixture's frame: yaw, then pitch, then roll
+	dx, dy, dz := target.X-pose.X, target.Y-pose.Y, -pose.Z
+	sin, cos := math.Sincos(-pose.Yaw)
+	dx, dy = cos*dx-sin*dy, sin*dx+cos*dy
+	sin, cos = math.Sincos(-pose.Pitch)
+	dx, dz = cos*dx+sin*dz, -sin*dx+cos*dz
+	sin, cos = math.Sincos(-pose.Roll)
+	dy, dz = cos*dy-sin*dz, sin*dy+cos*dz
+
+	pan := math.Atan2(dy, dx)
+	tilt := math.Atan2(math.Hypot(dx, dy), -dz)
+	return pan, tilt
+}
+
+// PanTilt returns the pan/tilt values aiming the fixture at target.
+func (pose FixturePose) PanTilt(target Point) (float64, float64) {
+	pan, tilt := pose.angles(target)
+	return pose.PanOffset + pose.PanScale*pan, pose.TiltOffset + pose.TiltScale*tilt
+}
+
+// PanRangeDegrees returns how many degrees the full 16 bit pan range covers.
+func (pose FixturePose) PanRangeDegrees() float64 {
+	return math.Abs(maxPanTiltValue/pose.PanScale) * 180 / math.Pi
+}
+
+// TiltRangeDegrees returns how many degrees the full 16 bit tilt range covers.
+func (pose FixturePose) TiltRangeDegrees() float64 {
+	return math.Abs(maxPanTiltValue/pose.TiltScale) * 180 / math.Pi
+}
+
+// aimYawAtSamples points Yaw at the average direction of the samples so pan angles stay away from the wrap.
+func aimYawAtSamples(pose *FixturePose, samples []PoseSample) {
+	var sumX, sumY float64
+	for _, sample := range samples {
+		dx, dy := sample.Floor.X-pose.X, sample.Floor.Y-pose.Y
+		length := math.Hypot(dx, dy)
+		if length > 0 {
+			sumX += dx / length
+			sumY += dy / length
+		}
+	}
+	pose.Yaw = math.Atan2(sumY, sumX)
+}
+
+// fitPoseLinear fits the pan/tilt offsets and scales for the pose position and orientation by
+// linear least squares. Returns the residual of every pan and tilt value.
+func fitPoseLinear(pose *FixturePose, samples []PoseSample) ([]float64, error) {
+	panRows := make([][]float64, len(samples))
+	tiltRows := make([][]float64, len(samples))
+	panValues := make([]float64, len(samples))
+	tiltValues := make([]float64, len(samples))
+	for i, sample := range samples {
+		pan, tilt := pose.angles(sample.Floor)
+		panRows[i] = []float64{1, pan}
+		tiltRows[i] = []float64{1, tilt}
+		panValues[i] = sample.Pan
+		tiltValues[i] = sample.Tilt
+	}
+
+	panFit, err := leastSquares(panRows, panValues)
+	if err != nil {
+		return nil, err
+	}
+	tiltFit, err := leastSquares(tiltRows, tiltValues)
+	if err != nil {
+		return nil, err
+	}
+	pose.PanOffset, pose.PanScale = panFit[0], panFit[1]
+	pose.TiltOffset, pose.TiltScale = tiltFit[0], tiltFit[1]
+
+	return poseResiduals(*pose, samples), nil
+}
+
+// poseResiduals returns the pan and tilt error of the pose for every sample, interleaved.
+func poseResiduals(pose FixturePose, samples []PoseSample) []float64 {
+	residuals := make([]float64, 0, 2*len(samples))
+	for _, sample := range samples {
+		pan, tilt := pose.PanTilt(sample.Floor)
+		residuals = append(residuals, pan-sample.Pan, tilt-sample.Tilt)
+	}
+	return residuals
+}
+
+func sumOfSquares(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v * v
+	}
+	return sum
+}
+
+// PoseSolution is a solved fixture pose together with how well it fits each calibration sample.
+type PoseSolution struct {
+	Pose      FixturePose
+	RMSError  float64
+	Residuals []PoseResidual // in the order of the samples
+}
+
+// PoseResidual is the error of a solved pose at one calibration sample.
+type PoseResidual struct {
+	Id      string
+	Pan     float64
+	Tilt    float64
+	Outlier bool // the error is far larger than for the other samples, likely a mis-clicked calibration
+}
+
+// Samples with an error larger than this many times the typical error, and larger than
+// outlierMinimumError, are reported as outliers.
+const (
+	outlierFactor       = 4.0
+	outlierMinimumError = 0.01 * maxPanTiltValue
+)
+
+// solveFixturePose finds the pose that best explains the calibration samples of a fixture.
+//
+// Without a known pose the position and mounting orientation are solved, which needs at least 5
+// samples, with 4 samples the fixture is assumed to hang level. With a known pose (e.g. from the
+// rig plan or another venue) the geometry is kept and only the pan/tilt mapping is fitted, which
+// needs 2 samples, with fewer the known pose is used as is.
+func solveFixturePose(samples []PoseSample, known *FixturePose) (PoseSolution, error) {
+	var pose FixturePose
+	switch {
+	case known != nil && len(samples) < 2:
+		pose = *known
+	case known != nil:
+		pose = *known
+		if _, err := fitPoseLinear(&pose, samples); err != nil {
+			return PoseSolution{}, errors.New("could not fit pan/tilt to the known pose, the calibration samples are degenerate")
+		}
+	case len(samples) >= 5:
+		var err error
+		pose, err = searchFixturePose(samples, true)
+		if err != nil {
+			return PoseSolution{}, err
+		}
+	case len(samples) == 4:
+		var err error
+		pose, err = searchFixturePose(samples, false)
+		if err != nil {
+			return PoseSolution{}, err
+		}
+	default:
+		return PoseSolution{}, errors.New("at least 4 calibration points with floor positions are needed to solve the fixture pose")
+	}
+
+	solution := newPoseSolution(pose, samples)
+	if known != nil || len(samples) < 5 || solution.RMSError < outlierMinimumError {
+		return solution, nil
+	}
+
+	// A single mis-clicked sample drags the least squares solution far off, so that the bad sample
+	// does not even stand out. Solve without each sample in turn and keep the pose the others agree on.
+	bestExcluded := -1
+	bestRMS := solution.RMSError
+	bestPose := pose
+	for excluded := range samples {
+		subset := make([]PoseSample, 0, len(samples)-1)
+		subset = append(subset, samples[:excluded]...)
+		subset = append(subset, samples[excluded+1:]...)
+
+		subsetPose, err := searchFixturePose(subset, len(subset) >= 5)
+		if err != nil {
+			continue
+		}
+		subsetRMS := math.Sqrt(sumOfSquares(poseResiduals(subsetPose, subset)) / float64(2*len(subset)))
+		if subsetRMS < bestRMS {
+			bestExcluded, bestRMS, bestPose = excluded, subsetRMS, subsetPose
+		}
+	}
+
+	if bestExcluded >= 0 && bestRMS < solution.RMSError/outlierFactor {
+		return newPoseSolution(bestPose, samples), nil
+	}
+	return solution, nil
+}
+
+func newPoseSolution(pose FixturePose, samples []PoseSample) PoseSolution {
+	solution := PoseSolution{Pose: pose, Residuals: make([]PoseResidual, len(samples))}
+	if len(samples) == 0 {
+		return solution
+	}
+
+	residuals := poseResiduals(pose, samples)
+	solution.RMSError = math.Sqrt(sumOfSquares(residuals) / float64(len(residuals)))
+
+	// The median is robust against the outliers we are looking for
+	errs := make([]float64, len(samples))
+	for i := range samples {
+		errs[i] = math.Hypot(residuals[2*i], residuals[2*i+1])
+	}
+	sorted := append([]float64(nil), errs...)
+	sort.Float64s(sorted)
+	typical := sorted[len(sorted)/2]
+
+	for i, sample := range samples {
+		solution.Residuals[i] = PoseResidual{
+			Id:      sample.Id,
+			Pan:     residuals[2*i],
+			Tilt:    residuals[2*i+1],
+			Outlier: errs[i] > outlierMinimumError && errs[i] > outlierFactor*typical,
+		}
+	}
+	return solution
+}
+
+// searchFixturePose refines the pose from a grid of starting positions around the calibrated area
+// with Levenberg-Marquardt and keeps the best. The pan/tilt mapping is solved exactly for every
+// candidate, so only the position (and the mounting tilt if withOrientation) is searched.
+func searchFixturePose(samples []PoseSample, withOrientation bool) (FixturePose, error) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sample := range samples {
+		minX, maxX = math.Min(minX, sample.Floor.X), math.Max(maxX, sample.Floor.X)
+		minY, maxY = math.Min(minY, sample.Floor.Y), math.Max(maxY, sample.Floor.Y)
+	}
+	size := math.Max(math.Max(maxX-minX, maxY-minY), 1e-3)
+	centerX, centerY := (minX+maxX)/2, (minY+maxY)/2
+
+	best := FixturePose{}
+	bestCost := math.Inf(1)
+	for _, gx := range []float64{-1, 0, 1} {
+		for _, gy := range []float64{-1, 0, 1} {
+			for _, gz := range []float64{0.5, 1, 2} {
+				start := []float64{centerX + gx*size, centerY + gy*size, gz * size}
+				if withOrientation {
+					start = append(start, 0, 0)
+				}
+				pose, cost, err := refinePose(samples, start, size)
+				if err == nil && cost < bestCost {
+					best, bestCost = pose, cost
+				}
+			}
+		}
+	}
+
+	if math.IsInf(bestCost, 1) {
+		return FixturePose{}, errors.New("could not solve the fixture pose, the calibration samples are degenerate")
+	}
+	return best, nil
+}
+
+// refinePose runs Levenberg-Marquardt on the fixture position, and mounting roll/pitch if start has
+// five values, starting at start.
+func refinePose(samples []PoseSample, start []float64, size float64) (FixturePose, float64, error) {
+	evaluate := func(p []float64) (FixturePose, []float64, error) {
+		pose := FixturePose{X: p[0], Y: p[1], Z: math.Abs(p[2])}
+		aimYawAtSamples(&pose, samples)
+		if len(p) == 5 {
+			pose.Roll, pose.Pitch = p[3], p[4]
+		}
+		residuals, err := fitPoseLinear(&pose, samples)
+		return pose, residuals, err
+	}
+
+	n := len(start)
+	p := append([]float64(nil), start...)
+	pose, residuals, err := evaluate(p)
+	if err != nil {
+		return FixturePose{}, 0, err
+	}
+	cost := sumOfSquares(residuals)
+	lambda := 1e-3
+
+	for iteration := 0; iteration < 100; iteration++ {
+		// Numerical Jacobian of the residuals with respect to the parameters
+		jacobian := make([][]float64, len(residuals))
+		for i := range jacobian {
+			jacobian[i] = make([]float64, n)
+		}
+		for k := 0; k < n; k++ {
+			step := 1e-6 * size
+			if k >= 3 {
+				step = 1e-6 // angles
+			}
+			shifted := append([]float64(nil), p...)
+			shifted[k] += step
+			_, shiftedResiduals, err := evaluate(shifted)
+			if err != nil {
+				return pose, cost, nil
+			}
+			for i := range residuals {
+				jacobian[i][k] = (shiftedResiduals[i] - residuals[i]) / step
+			}
+		}
+
+		improved := false
+		for !improved && lambda < 1e10 {
+			A := make([][]float64, n)
+			b := make([]float64, n)
+			for r := 0; r < n; r++ {
+				A[r] = make([]float64, n)
+				for c := 0; c < n; c++ {
+					for i := range residuals {
+						A[r][c] += jacobian[i][r] * jacobian[i][c]
+					}
+				}
+				A[r][r] *= 1 + lambda
+				for i := range residuals {
+					b[r] -= jacobian[i][r] * residuals[i]
+				}
+			}
+
+			delta, err := solveLinearSystem(A, b)
+			if err != nil {
+				lambda *= 10
+				continue
+			}
+
+			candidate := make([]float64, n)
+			for k := range candidate {
+				candidate[k] = p[k] + delta[k]
+			}
+			candidatePose, candidateResiduals, err := evaluate(candidate)
+			if err != nil || sumOfSquares(candidateResiduals) >= cost {
+				lambda *= 10
+				continue
+			}
+
+			improvement := cost - sumOfSquares(candidateResiduals)
+			p, pose, residuals = candidate, candidatePose, candidateResiduals
+			cost = sumOfSquares(residuals)
+			lambda /= 10
+			improved = true
+
+			if improvement < 1e-9*(cost+1) {
+				return pose, cost, nil
+			}
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return pose, cost, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// stageFloor is a grid of floor positions in meters, as calibrated on a 10 by 8 m stage.
+var stageFloor = []Point{
+	{X: 1, Y: 1}, {X: 5, Y: 1.5}, {X: 9, Y: 1}, {X: 2, Y: 4}, {X: 6, Y: 4.5},
+	{X: 8.5, Y: 5}, {X: 1.5, Y: 7}, {X: 5, Y: 7.5}, {X: 9, Y: 7},
+}
+
+func samplesFromPose(pose FixturePose, floor []Point) []PoseSample {
+	samples := make([]PoseSample, len(floor))
+	for i, p := range floor {
+		pan, tilt := pose.PanTilt(p)
+		samples[i] = PoseSample{Id: fmt.Sprint(i), Floor: p, Pan: pan, Tilt: tilt}
+	}
+	return samples
+}
+
+func TestSolveFixturePoseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		pose    FixturePose
+		samples int
+	}{
+		{
+			name:    "hanging level, 4 samples",
+			pose:    FixturePose{X: 5, Y: -2, Z: 6, Yaw: math.Pi / 2, PanOffset: 32768, PanScale: 65535 / (3 * math.Pi), TiltOffset: 20000, TiltScale: 65535 / (1.5 * math.Pi)},
+			samples: 4,
+		},
+		{
+			name:    "hanging level, all samples",
+			pose:    FixturePose{X: 2, Y: 9, Z: 5, Yaw: -math.Pi / 3, PanOffset: 30000, PanScale: -65535 / (3 * math.Pi), TiltOffset: 10000, TiltScale: 65535 / (1.5 * math.Pi)},
+			samples: len(stageFloor),
+		},
+		{
+			name:    "mounted at an angle",
+			pose:    FixturePose{X: 4, Y: -1, Z: 7, Yaw: math.Pi / 2, Roll: 0.1, Pitch: -0.08, PanOffset: 32768, PanScale: 65535 / (3 * math.Pi), TiltOffset: 15000, TiltScale: 65535 / (1.5 * math.Pi)},
+			samples: len(stageFloor),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			solution, err := solveFixturePose(samplesFromPose(test.pose, stageFloor[:test.samples]), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if solution.RMSError > 1 {
+				t.Errorf("RMS error %v", solution.RMSError)
+			}
+			got := solution.Pose
+			if math.Abs(got.X-test.pose.X) > 0.05 || math.Abs(got.Y-test.pose.Y) > 0.05 || math.Abs(got.Z-test.pose.Z) > 0.05 {
+				t.Errorf("solved position %v, %v, %v, want %v, %v, %v", got.X, got.Y, got.Z, test.pose.X, test.pose.Y, test.pose.Z)
+			}
+
+			// Away from the calibrated positions too
+			for _, p := range []Point{{X: 3, Y: 3}, {X: 7, Y: 6}, {X: 0, Y: 8}} {
+				wantPan, wantTilt := test.pose.PanTilt(p)
+				gotPan, gotTilt := got.PanTilt(p)
+				if math.Abs(gotPan-wantPan) > 10 || math.Abs(gotTilt-wantTilt) > 10 {
+					t.Errorf("%v aims at %v, %v, want %v, %v", p, gotPan, gotTilt, wantPan, wantTilt)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveFixturePoseWithKnownPose(t *testing.T) {
+	pose := FixturePose{X: 5, Y: -2, Z: 6, Yaw: math.Pi / 2, PanOffset: 32768, PanScale: 65535 / (3 * math.Pi), TiltOffset: 20000, TiltScale: 65535 / (1.5 * math.Pi)}
+	known := pose
+	known.PanOffset, known.PanScale, known.TiltOffset, known.TiltScale = 0, 1, 0, 1
+
+	solution, err := solveFixturePose(samplesFromPose(pose, stageFloor[:2]), &known)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := solution.Pose
+	if math.Abs(got.PanOffset-pose.PanOffset) > 1e-6 || math.Abs(got.PanScale-pose.PanScale) > 1e-6 ||
+		math.Abs(got.TiltOffset-pose.TiltOffset) > 1e-6 || math.Abs(got.TiltScale-pose.TiltScale) > 1e-6 {
+		t.Errorf("fitted %+v, want %+v", got, pose)
+	}
+	if got.X != known.X || got.Y != known.Y || got.Z != known.Z {
+		t.Errorf("moved the known position to %v, %v, %v", got.X, got.Y, got.Z)
+	}
+}
+
+func TestSolveFixturePoseFindsOutlier(t *testing.T) {
+	pose := FixturePose{X: 5, Y: -2, Z: 6, Yaw: math.Pi / 2, PanOffset: 32768, PanScale: 65535 / (3 * math.Pi), TiltOffset: 20000, TiltScale: 65535 / (1.5 * math.Pi)}
+	samples := samplesFromPose(pose, stageFloor)
+	samples[4].Pan += 3000 // mis-clicked
+
+	solution, err := solveFixturePose(samples, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, residual := range solution.Residuals {
+		if residual.Outlier != (i == 4) {
+			t.Errorf("sample %d outlier %v, pan error %v", i, residual.Outlier, residual.Pan)
+		}
+	}
+}
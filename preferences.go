@@ -7,10 +7,11 @@ import (
 )
 
 type Preferences struct {
-	LastConfigPath       string `json:"lastConfigPath"`
-	LastIpAddress        string `json:"lastIpAddress"`
-	LastVideoSourceId    string `json:"lastVideoSourceId"`
-	LastVideoSourceLabel string `json:"lastVideoSourceLabel"`
+	LastConfigPath       string        `json:"lastConfigPath"`
+	LastIpAddress        string        `json:"lastIpAddress"`
+	LastVideoSourceId    string        `json:"lastVideoSourceId"`
+	LastVideoSourceLabel string        `json:"lastVideoSourceLabel"`
+	Remote               *RemoteConfig `json:"remote,omitempty"`
 }
 
 func getPreferencesPath() (string, error) {
@@ -0,0 +1,69 @@
+package main
+
+import "time"
+
+const (
+	defaultSACNPriority = 100
+	maxSACNPriority     = 200
+
+	// startCodePerAddressPriority is the start code of per-address priority packets, which
+	// receivers supporting it (e.g. ETC nodes) use instead of the universe priority.
+	startCodePerAddressPriority = 0xDD
+
+	// perAddressPriorityInterval is how often per-address priority is sent when it has not changed,
+	// receivers only need it occasionally besides the level data.
+	perAddressPriorityInterval = time.Second
+)
+
+// sentPriorities is the per-address priority last sent on a universe.
+type sentPriorities struct {
+	priorities DMXData
+	sent       time.Time
+}
+
+// priority returns the sACN priority of the universe, 0 means the default priority.
+func (config SACNUniverseConfig) priority() uint8 {
+	if config.Priority == 0 {
+		return defaultSACNPriority
+	}
+	return min(config.Priority, maxSACNPriority)
+}
+
+// perAddressPriorities returns the per-address priority of a universe: the universe priority on the
+// addresses Följe drives and 0 (not sourced) on all others, so they are left to other sources.
+func (a *App) perAddressPriorities(uni uint16) DMXData {
+	priority := a.sacnConfig.universeConfig(uni).priority()
+
+	priorities := DMXData{}
+	for _, address := range a.followedAddresses(uni) {
+		priorities[address] = priority
+	}
+	return priorities
+}
+
+// perAddressPrioritiesToSend returns the per-address priority of the universe and true if it is enabled
+// and the priorities have changed or not been sent for a while.
+func (a *App) perAddressPrioritiesToSend(uni uint16, now time.Time) (DMXData, bool) {
+	if !a.sacnConfig.universeConfig(uni).PerAddressPriority {
+		delete(a.sentPriorities, uni)
+		return DMXData{}, false
+	}
+
+	priorities := a.perAddressPriorities(uni)
+	sent, exists := a.sentPriorities[uni]
+	if exists && sent.priorities == priorities && now.Sub(sent.sent) < perAddressPriorityInterval {
+		return DMXData{}, false
+	}
+	a.sentPriorities[uni] = &sentPriorities{priorities: priorities, sent: now}
+	return priorities, true
+}
+
+// perAddressPriorityWait returns how long until the per-address priority of the universe is due to be
+// sent again, and false if it is not being sent.
+func (a *App) perAddressPriorityWait(uni uint16, now time.Time) (time.Duration, bool) {
+	sent, exists := a.sentPriorities[uni]
+	if !exists {
+		return 0, false
+	}
+	return max(perAddressPriorityInterval-now.Sub(sent.sent), 0), true
+}
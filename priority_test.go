@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func newPriorityTestApp(config SACNUniverseConfig) *App {
+	return &App{
+		sacnConfig: &SACNConfig{Universes: []SACNUniverseConfig{config}},
+		fixtures: map[string]Fixture{
+			"spot": {Id: "spot", Universe: 1, PanAddress: 0, FinePanAddress: 1, TiltAddress: 2, FineTiltAddress: 3,
+				Channels: []FixtureChannel{{Id: "zoom", Address: 9, FineAddress: -1}}},
+			"wash":      {Id: "wash", Universe: 1, PanAddress: 20, FinePanAddress: -1, TiltAddress: 21, FineTiltAddress: -1},
+			"released":  {Id: "released", Universe: 1, PanAddress: 30, FinePanAddress: -1, TiltAddress: 31, FineTiltAddress: -1},
+			"elsewhere": {Id: "elsewhere", Universe: 2, PanAddress: 40, FinePanAddress: -1, TiltAddress: 41, FineTiltAddress: -1},
+		},
+		fixtureControls: map[string]FixtureControl{"released": {Mode: FixtureModeRelease}},
+		sentPriorities:  make(map[uint16]*sentPriorities),
+	}
+}
+
+func TestPerAddressPriorities(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority uint8
+		want     uint8
+	}{
+		{"default", 0, defaultSACNPriority},
+		{"configured", 150, 150},
+		{"above the maximum", 250, maxSACNPriority},
+	}
+	for _, test := range tests {
+		a := newPriorityTestApp(SACNUniverseConfig{Universe: 1, Priority: test.priority, PerAddressPriority: true})
+		priorities := a.perAddressPriorities(1)
+
+		followed := map[int]bool{0: true, 1: true, 2: true, 3: true, 9: true, 20: true, 21: true}
+		for address, priority := range priorities {
+			want := uint8(0) // not sourced, left to the console
+			if followed[address] {
+				want = test.want
+			}
+			if priority != want {
+				t.Errorf("%s: address %d has priority %d, want %d", test.name, address, priority, want)
+			}
+		}
+	}
+}
+
+func TestPerAddressPrioritiesToSend(t *testing.T) {
+	a := newPriorityTestApp(SACNUniverseConfig{Universe: 1, PerAddressPriority: true})
+	start := time.Now()
+
+	steps := []struct {
+		name  string
+		at    time.Duration
+		patch func()
+		want  bool
+	}{
+		{"first", 0, nil, true},
+		{"unchanged", 100 * time.Millisecond, nil, false},
+		{"patch changed", 200 * time.Millisecond, func() {
+			a.fixtureControls["released"] = FixtureControl{Mode: FixtureModeFollow}
+		}, true},
+		{"unchanged again", 300 * time.Millisecond, nil, false},
+		{"refresh", 200*time.Millisecond + perAddressPriorityInterval, nil, true},
+		{"disabled", 2 * perAddressPriorityInterval, func() {
+			a.sacnConfig.Universes[0].PerAddressPriority = false
+		}, false},
+	}
+	for _, step := range steps {
+		if step.patch != nil {
+			step.patch()
+		}
+		if _, send := a.perAddressPrioritiesToSend(1, start.Add(step.at)); send != step.want {
+			t.Errorf("%s: send %v, want %v", step.name, send, step.want)
+		}
+	}
+	if _, exists := a.sentPriorities[1]; exists {
+		t.Error("kept the sent priorities after disabling per-address priority")
+	}
+}
+
+func TestSACNOutputSendsPerAddressPriorityPacket(t *testing.T) {
+	output := newTestSACNOutput(t)
+	node := listenUDP(t)
+	if err := output.StartUniverse(1); err != nil {
+		t.Fatal(err)
+	}
+	output.SetRouting(1, false, []string{node.LocalAddr().String()})
+
+	priorities := DMXData{}
+	priorities[0], priorities[511] = 150, 150
+	if err := output.Send(1, startCodePerAddressPriority, priorities, 150); err != nil {
+		t.Fatal(err)
+	}
+
+	p := receiveSACNData(t, node)
+	if p.GetStartCode() != startCodePerAddressPriority {
+		t.Errorf("start code %#x, want %#x", p.GetStartCode(), startCodePerAddressPriority)
+	}
+	if p.Priority != 150 {
+		t.Errorf("universe priority %d, want 150", p.Priority)
+	}
+	if data := p.GetData(); len(data) != 512 || data[0] != 150 || data[1] != 0 || data[511] != 150 {
+		t.Errorf("data %v, want 512 per-address priorities", data)
+	}
+}
+
+func TestPerAddressPriorityWait(t *testing.T) {
+	a := newPriorityTestApp(SACNUniverseConfig{Universe: 1, PerAddressPriority: true})
+	start := time.Now()
+
+	if _, sending := a.perAddressPriorityWait(1, start); sending {
+		t.Error("waiting for per-address priority before sending any")
+	}
+	a.perAddressPrioritiesToSend(1, start)
+
+	tests := []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{0, perAddressPriorityInterval},
+		{300 * time.Millisecond, perAddressPriorityInterval - 300*time.Millisecond},
+		{perAddressPriorityInterval, 0},
+		{2 * perAddressPriorityInterval, 0},
+	}
+	for _, test := range tests {
+		wait, sending := a.perAddressPriorityWait(1, start.Add(test.at))
+		if !sending || wait != test.want {
+			t.Errorf("at %s: wait %s, %v, want %s, true", test.at, wait, sending, test.want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Channel attributes Följe knows how to drive, named after the GDTF attributes.
+const (
+	ChannelAttributePan  = "Pan"
+	ChannelAttributeTilt = "Tilt"
+)
+
+func getProfilesPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "Folje", "profiles"), nil
+}
+
+// loadFixtureProfiles reads all profiles in the profile library.
+func loadFixtureProfiles() map[string]FixtureProfile {
+	profiles := make(map[string]FixtureProfile)
+
+	dir, err := getProfilesPath()
+	if err != nil {
+		LogError("Failed to get profiles path: %s", err.Error())
+		return profiles
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		LogError("Failed to list fixture profiles: %s", err.Error())
+		return profiles
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			LogError("Failed to read fixture profile %s: %s", file, err.Error())
+			continue
+		}
+
+		var profile FixtureProfile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			LogError("Failed to parse fixture profile %s: %s", file, err.Error())
+			continue
+		}
+		profiles[profile.Id] = profile
+	}
+
+	LogInfo("Loaded %d fixture profile(s) from %s", len(profiles), dir)
+	return profiles
+}
+
+func saveFixtureProfile(profile FixtureProfile) error {
+	dir, err := getProfilesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, profile.Id+".json"), data, 0644)
+}
+
+var profileIdInvalidCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// profileId returns a file name safe id for a fixture type, the same fixture type always gets the
+// same id so importing it again replaces the old profile.
+func profileId(manufacturer string, name string) string {
+	id := strings.ToLower(manufacturer + "-" + name)
+	return strings.Trim(profileIdInvalidCharacters.ReplaceAllString(id, "-"), "-")
+}
+
+// parseFixtureProfile parses a GDTF file or an Open Fixture Library JSON file.
+func parseFixtureProfile(path string) (FixtureProfile, error) {
+	var profile FixtureProfile
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gdtf":
+		profile, err = parseGDTF(path)
+	case ".json":
+		profile, err = parseOFL(path)
+	default:
+		return FixtureProfile{}, fmt.Errorf("unknown fixture profile format %s, expected .gdtf or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return FixtureProfile{}, err
+	}
+
+	if len(profile.Modes) == 0 {
+		return FixtureProfile{}, errors.New("the fixture profile has no modes")
+	}
+	profile.Id = profileId(profile.Manufacturer, profile.Name)
+	profile.Source = filepath.Base(path)
+	return profile, nil
+}
+
+// mode returns the mode with the given name, or the first mode if name is empty.
+func (profile FixtureProfile) mode(name string) (FixtureProfileMode, bool) {
+	for _, mode := range profile.Modes {
+		if mode.Name == name || name == "" {
+			return mode, true
+		}
+	}
+	return FixtureProfileMode{}, false
+}
+
+// channel returns the first channel in the mode with the given attribute.
+func (mode FixtureProfileMode) channel(attribute string) (ProfileChannel, bool) {
+	for _, channel := range mode.Channels {
+		if channel.Attribute == attribute {
+			return channel, true
+		}
+	}
+	return ProfileChannel{}, false
+}
+
+// channelNamed returns the channel in the mode with the given name.
+func (mode FixtureProfileMode) channelNamed(name string) (ProfileChannel, bool) {
+	for _, channel := range mode.Channels {
+		if channel.Name == name {
+			return channel, true
+		}
+	}
+	return ProfileChannel{}, false
+}
+
+// resolveFixture sets the pan/tilt and channel addresses of a fixture using a profile from its start address and mode.
+func resolveFixture(fixture Fixture, profiles map[string]FixtureProfile) (Fixture, error) {
+	if fixture.ProfileId == "" {
+		return fixture, nil
+	}
+
+	profile, exists := profiles[fixture.ProfileId]
+	if !exists {
+		return fixture, fmt.Errorf("fixture profile %s is not in the profile library", fixture.ProfileId)
+	}
+	mode, exists := profile.mode(fixture.ProfileMode)
+	if !exists {
+		return fixture, fmt.Errorf("fixture profile %s has no mode %s", profile.Name, fixture.ProfileMode)
+	}
+
+	// addresses returns the coarse and fine address of a channel, -1 for a missing fine channel
+	addresses := func(channel ProfileChannel) (int, int, error) {
+		if len(channel.Offsets) == 0 {
+			return -1, -1, fmt.Errorf("channel %s of fixture profile %s has no offsets", channel.Name, profile.Name)
+		}
+		coarse, fine := fixture.StartAddress+channel.Offsets[0], -1
+		if len(channel.Offsets) > 1 {
+			fine = fixture.StartAddress + channel.Offsets[1]
+		}
+		return coarse, fine, nil
+	}
+	attributeAddresses := func(attribute string) (int, int, error) {
+		channel, exists := mode.channel(attribute)
+		if !exists {
+			return -1, -1, nil
+		}
+		return addresses(channel)
+	}
+	var err error
+	if fixture.PanAddress, fixture.FinePanAddress, err = attributeAddresses(ChannelAttributePan); err != nil {
+		return fixture, err
+	}
+	if fixture.TiltAddress, fixture.FineTiltAddress, err = attributeAddresses(ChannelAttributeTilt); err != nil {
+		return fixture, err
+	}
+
+	channels := make([]FixtureChannel, len(fixture.Channels))
+	for i, channel := range fixture.Channels {
+		channels[i] = channel
+		if channel.ProfileChannel == "" {
+			continue
+		}
+		profileChannel, exists := mode.channelNamed(channel.ProfileChannel)
+		if !exists {
+			return fixture, fmt.Errorf("mode %s of fixture profile %s has no channel %s", mode.Name, profile.Name, channel.ProfileChannel)
+		}
+		if channels[i].Address, channels[i].FineAddress, err = addresses(profileChannel); err != nil {
+			return fixture, err
+		}
+	}
+	fixture.Channels = channels
+	return fixture, nil
+}
+
+// fixtureChannels returns the addresses a fixture occupies in its universe.
+func fixtureChannels(fixture Fixture, profiles map[string]FixtureProfile) []int {
+	if profile, exists := profiles[fixture.ProfileId]; exists {
+		if mode, exists := profile.mode(fixture.ProfileMode); exists {
+			channels := make([]int, mode.Footprint)
+			for i := range channels {
+				channels[i] = fixture.StartAddress + i
+			}
+			return channels
+		}
+	}
+
+	channels := make([]int, 0, 4+2*len(fixture.Channels))
+	addresses := []int{fixture.PanAddress, fixture.FinePanAddress, fixture.TiltAddress, fixture.FineTiltAddress}
+	for _, channel := range fixture.Channels {
+		addresses = append(addresses, channel.Address, channel.FineAddress)
+	}
+	for _, address := range addresses {
+		if address >= 0 {
+			channels = append(channels, address)
+		}
+	}
+	return channels
+}
+
+// patchProblems lists fixtures which overlap or do not fit in their universe. Addresses are shown
+// starting from 1 like in the fixture settings.
+func patchProblems(fixtures map[string]Fixture, profiles map[string]FixtureProfile) []string {
+	ids := make([]string, 0, len(fixtures))
+	for id := range fixtures {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return fixtures[ids[i]].Name < fixtures[ids[j]].Name })
+
+	problems := make([]string, 0)
+	patched := make(map[uint16]map[int]string) // fixture id by universe and address
+	for _, id := range ids {
+		fixture := fixtures[id]
+		if patched[fixture.Universe] == nil {
+			patched[fixture.Universe] = make(map[int]string)
+		}
+
+		overlapping := make(map[string]int) // first overlapping address by fixture id
+		for _, address := range fixtureChannels(fixture, profiles) {
+			if address >= 512 {
+				problems = append(problems, fmt.Sprintf("%s does not fit in universe %d", fixture.Name, fixture.Universe))
+				break
+			}
+			if other, exists := patched[fixture.Universe][address]; exists && other != id {
+				if _, reported := overlapping[other]; !reported {
+					overlapping[other] = address
+				}
+				continue
+			}
+			patched[fixture.Universe][address] = id
+		}
+
+		for other, address := range overlapping {
+			problems = append(problems, fmt.Sprintf("%s overlaps %s in universe %d at address %d", fixture.Name, fixtures[other].Name, fixture.Universe, address+1))
+		}
+	}
+	return problems
+}
+
+// writeProfileDefaults writes the default value of every channel of fixtures using a profile,
+// except pan/tilt which are set by following. Fixture channels then get their own default.
+func (a *App) writeProfileDefaults() {
+	for _, fixture := range a.fixtures {
+		profile, exists := a.fixtureProfiles[fixture.ProfileId]
+		if !exists {
+			continue
+		}
+		mode, exists := profile.mode(fixture.ProfileMode)
+		if !exists {
+			continue
+		}
+
+		data := a.universeDMXData[fixture.Universe]
+		for _, channel := range mode.Channels {
+			if channel.Attribute == ChannelAttributePan || channel.Attribute == ChannelAttributeTilt {
+				continue
+			}
+			for i, offset := range channel.Offsets {
+				address := fixture.StartAddress + offset
+				if address < 0 || address >= 512 {
+					continue
+				}
+				shift := 8 * (len(channel.Offsets) - 1 - i)
+				data[address] = byte(channel.Default >> shift)
+			}
+		}
+		a.universeDMXData[fixture.Universe] = data
+	}
+
+	for _, fixture := range a.fixtures {
+		for _, channel := range fixture.Channels {
+			a.setChannelForFixture(fixture, channel, channel.Default)
+		}
+	}
+}
+
+// ImportFixtureProfile asks for a GDTF or Open Fixture Library file and adds it to the profile
+// library. Returns the id of the imported profile, or an empty string if nothing was imported.
+func (a *App) ImportFixtureProfile() string {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Fixture Profile",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Fixture Profiles (*.gdtf, *.json)",
+				Pattern:     "*.gdtf;*.json",
+			},
+		}})
+	if err != nil {
+		runtime.LogError(a.ctx, err.Error())
+		return ""
+	}
+
+	// User cancelled the dialog
+	if file == "" {
+		return ""
+	}
+
+	profile, err := parseFixtureProfile(file)
+	if err != nil {
+		LogError("Failed to import fixture profile %s: %s", file, err.Error())
+		a.AlertDialog("Failed to import fixture profile", err.Error())
+		return ""
+	}
+
+	if err := saveFixtureProfile(profile); err != nil {
+		LogError("Failed to save fixture profile %s: %s", profile.Id, err.Error())
+		a.AlertDialog("Failed to import fixture profile", err.Error())
+		return ""
+	}
+
+	LogInfo("Imported fixture profile %s (%s %s) with %d mode(s) from %s", profile.Id, profile.Manufacturer, profile.Name, len(profile.Modes), file)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fixtureProfiles[profile.Id] = profile
+	return profile.Id
+}
+
+// GetFixtureProfiles returns the profile library sorted by manufacturer and name.
+func (a *App) GetFixtureProfiles() []FixtureProfile {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	profiles := make([]FixtureProfile, 0, len(a.fixtureProfiles))
+	for _, profile := range a.fixtureProfiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Manufacturer != profiles[j].Manufacturer {
+			return profiles[i].Manufacturer < profiles[j].Manufacturer
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// GetPatchProblems returns the problems found with the patch when the fixtures were last set.
+func (a *App) GetPatchProblems() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.patchProblems
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testGDTFDescription = `<?xml version="1.0" encoding="UTF-8"?>
+<GDTF DataVersion="1.1">
+  <FixtureType Name="Spot" LongName="Test Spot 300" Manufacturer="Acme">
+    <DMXModes>
+      <DMXMode Name="Standard">
+        <DMXChannels>
+          <DMXChannel DMXBreak="1" Offset="1,2">
+            <LogicalChannel Attribute="Pan">
+              <ChannelFunction Attribute="Pan" Default="128/1"/>
+            </LogicalChannel>
+          </DMXChannel>
+          <DMXChannel DMXBreak="1" Offset="3,4" Default="32768/2">
+            <LogicalChannel Attribute="Tilt"/>
+          </DMXChannel>
+          <DMXChannel DMXBreak="1" Offset="5">
+            <LogicalChannel Attribute="Dimmer">
+              <ChannelFunction Attribute="Dimmer" Default="255/1"/>
+            </LogicalChannel>
+          </DMXChannel>
+          <DMXChannel DMXBreak="2" Offset="1">
+            <LogicalChannel Attribute="ColorAdd_R"/>
+          </DMXChannel>
+          <DMXChannel DMXBreak="1" Offset="">
+            <LogicalChannel Attribute="Virtual"/>
+          </DMXChannel>
+        </DMXChannels>
+      </DMXMode>
+      <DMXMode Name="Basic">
+        <DMXChannels>
+          <DMXChannel DMXBreak="1" Offset="1">
+            <LogicalChannel Attribute="Pan"/>
+          </DMXChannel>
+          <DMXChannel DMXBreak="1" Offset="2">
+            <LogicalChannel Attribute="Tilt">
+              <ChannelFunction Attribute="Tilt" Default="255/1s"/>
+            </LogicalChannel>
+          </DMXChannel>
+        </DMXChannels>
+      </DMXMode>
+    </DMXModes>
+  </FixtureType>
+</GDTF>`
+
+// writeGDTF writes a GDTF archive with the given description.xml.
+func writeGDTF(t *testing.T, description string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.gdtf")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	writer, err := archive.Create("description.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write([]byte(description)); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseGDTF(t *testing.T) {
+	profile, err := parseFixtureProfile(writeGDTF(t, testGDTFDescription))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := FixtureProfile{
+		Id:           "acme-test-spot-300",
+		Name:         "Test Spot 300",
+		Manufacturer: "Acme",
+		Source:       "fixture.gdtf",
+		Modes: []FixtureProfileMode{
+			{
+				Name:      "Standard",
+				Footprint: 5,
+				Channels: []ProfileChannel{
+					{Name: "Pan", Attribute: ChannelAttributePan, Offsets: []int{0, 1}, Default: 32896},
+					{Name: "Tilt", Attribute: ChannelAttributeTilt, Offsets: []int{2, 3}, Default: 32768},
+					{Name: "Dimmer", Attribute: "", Offsets: []int{4}, Default: 255},
+				},
+			},
+			{
+				Name:      "Basic",
+				Footprint: 2,
+				Channels: []ProfileChannel{
+					{Name: "Pan", Attribute: ChannelAttributePan, Offsets: []int{0}, Default: 0},
+					{Name: "Tilt", Attribute: ChannelAttributeTilt, Offsets: []int{1}, Default: 255},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(profile, want) {
+		t.Errorf("parsed\n%+v\nwant\n%+v", profile, want)
+	}
+}
+
+func TestGDTFDMXValue(t *testing.T) {
+	tests := []struct {
+		value string
+		bytes int
+		want  int
+	}{
+		{"128/1", 1, 128},
+		{"128/1", 2, 32896}, // byte mirroring
+		{"255/1", 2, 65535},
+		{"255/1s", 2, 65280}, // byte shifting
+		{"32768/2", 1, 128},
+		{"32768/2", 2, 32768},
+		{"0/1", 2, 0},
+		{"", 2, 0},
+		{"128", 1, 0},
+		{"x/1", 1, 0},
+		{"128/0", 1, 0},
+	}
+	for _, test := range tests {
+		if got := gdtfDMXValue(test.value, test.bytes); got != test.want {
+			t.Errorf("gdtfDMXValue(%q, %d) = %d, want %d", test.value, test.bytes, got, test.want)
+		}
+	}
+}
+
+func TestParseGDTFErrors(t *testing.T) {
+	badOffset := `<GDTF><FixtureType Name="Spot" Manufacturer="Acme"><DMXModes><DMXMode Name="Standard"><DMXChannels>
+<DMXChannel Offset="1,x"><LogicalChannel Attribute="Pan"/></DMXChannel>
+</DMXChannels></DMXMode></DMXModes></FixtureType></GDTF>`
+	noModes := `<GDTF><FixtureType Name="Spot" Manufacturer="Acme"><DMXModes/></FixtureType></GDTF>`
+
+	notZip := filepath.Join(t.TempDir(), "fixture.gdtf")
+	if err := os.WriteFile(notZip, []byte("not a zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"not a zip":  notZip,
+		"bad xml":    writeGDTF(t, "<GDTF><FixtureType"),
+		"bad offset": writeGDTF(t, badOffset),
+		"no modes":   writeGDTF(t, noModes),
+	} {
+		if _, err := parseFixtureProfile(path); err == nil {
+			t.Errorf("%s: parsed without error", name)
+		}
+	}
+}
+
+const testOFLFixture = `{
+  "name": "Test Beam",
+  "manufacturerKey": "robe-lighting",
+  "availableChannels": {
+    "Pan": { "fineChannelAliases": ["Pan fine"], "defaultValue": "50%", "capability": { "type": "Pan" } },
+    "Tilt": { "fineChannelAliases": ["Tilt fine"], "defaultValue": 32768, "capabilities": [{ "type": "Tilt" }] },
+    "Dimmer": { "capability": { "type": "Intensity" } }
+  },
+  "modes": [
+    { "name": "16 bit", "channels": ["Dimmer", "Pan", "Pan fine", null, "Tilt", "Tilt fine"] },
+    { "name": "8 bit", "channels": ["Pan", "Tilt"] }
+  ]
+}`
+
+func writeOFL(t *testing.T, fixture string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := os.WriteFile(path, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseOFL(t *testing.T) {
+	profile, err := parseFixtureProfile(writeOFL(t, testOFLFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := FixtureProfile{
+		Id:           "robe-lighting-test-beam",
+		Name:         "Test Beam",
+		Manufacturer: "Robe Lighting",
+		Source:       "fixture.json",
+		Modes: []FixtureProfileMode{
+			{
+				Name:      "16 bit",
+				Footprint: 6,
+				Channels: []ProfileChannel{
+					{Name: "Dimmer", Attribute: "", Offsets: []int{0}, Default: 0},
+					{Name: "Pan", Attribute: ChannelAttributePan, Offsets: []int{1, 2}, Default: 32768},
+					{Name: "Tilt", Attribute: ChannelAttributeTilt, Offsets: []int{4, 5}, Default: 32768},
+				},
+			},
+			{
+				Name:      "8 bit",
+				Footprint: 2,
+				Channels: []ProfileChannel{
+					{Name: "Pan", Attribute: ChannelAttributePan, Offsets: []int{0}, Default: 128},
+					{Name: "Tilt", Attribute: ChannelAttributeTilt, Offsets: []int{1}, Default: 128},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(profile, want) {
+		t.Errorf("parsed\n%+v\nwant\n%+v", profile, want)
+	}
+}
+
+func TestOFLDefaultValue(t *testing.T) {
+	tests := []struct {
+		defaultValue string
+		fineAliases  []string
+		bytes        int
+		want         int
+	}{
+		{``, nil, 1, 0},
+		{`"50%"`, nil, 1, 128},
+		{`"100%"`, []string{"fine"}, 2, 65535},
+		{`200`, nil, 1, 200},
+		{`32768`, []string{"fine"}, 2, 32768},
+		{`32768`, []string{"fine"}, 1, 128}, // defined at 16 bit, used at 8 bit
+		{`300`, nil, 1, 255},
+		{`"half"`, nil, 1, 0},
+	}
+	for _, test := range tests {
+		channel := oflChannel{DefaultValue: json.RawMessage(test.defaultValue), FineChannelAliases: test.fineAliases}
+		if got := channel.defaultValue(test.bytes); got != test.want {
+			t.Errorf("defaultValue %s at %d byte(s) = %d, want %d", test.defaultValue, test.bytes, got, test.want)
+		}
+	}
+}
+
+func TestParseOFLErrors(t *testing.T) {
+	tests := map[string]string{
+		"not json":        `{"name": `,
+		"no modes":        `{"name": "Test Beam", "modes": []}`,
+		"matrix channels": `{"name": "Test Beam", "modes": [{"name": "Matrix", "channels": [{"insert": "matrixChannels"}]}]}`,
+		"fine without coarse": `{"name": "Test Beam", "availableChannels": {"Pan": {"fineChannelAliases": ["Pan fine"]}},
+			"modes": [{"name": "Fine", "channels": ["Pan fine"]}]}`,
+	}
+	for name, fixture := range tests {
+		if _, err := parseFixtureProfile(writeOFL(t, fixture)); err == nil {
+			t.Errorf("%s: parsed without error", name)
+		}
+	}
+}
+
+func TestResolveFixture(t *testing.T) {
+	profile := func(panOffsets, dimmerOffsets []int) map[string]FixtureProfile {
+		return map[string]FixtureProfile{"spot": {Id: "spot", Name: "Spot", Modes: []FixtureProfileMode{{
+			Name: "16 bit",
+			Channels: []ProfileChannel{
+				{Name: "Pan", Attribute: ChannelAttributePan, Offsets: panOffsets},
+				{Name: "Tilt", Attribute: ChannelAttributeTilt, Offsets: []int{2}},
+				{Name: "Dimmer", Offsets: dimmerOffsets},
+			},
+		}}}}
+	}
+	fixture := Fixture{Id: "f", ProfileId: "spot", ProfileMode: "16 bit", StartAddress: 100, Channels: []FixtureChannel{{Id: "dim", ProfileChannel: "Dimmer"}}}
+
+	resolved, err := resolveFixture(fixture, profile([]int{0, 1}, []int{3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.PanAddress != 100 || resolved.FinePanAddress != 101 || resolved.TiltAddress != 102 || resolved.FineTiltAddress != -1 {
+		t.Errorf("pan %d/%d, tilt %d/%d, want 100/101, 102/-1", resolved.PanAddress, resolved.FinePanAddress, resolved.TiltAddress, resolved.FineTiltAddress)
+	}
+	if channel := resolved.Channels[0]; channel.Address != 103 || channel.FineAddress != -1 {
+		t.Errorf("dimmer %d/%d, want 103/-1", channel.Address, channel.FineAddress)
+	}
+
+	// A profile from a show file may have channels without offsets
+	if _, err := resolveFixture(fixture, profile(nil, []int{3})); err == nil {
+		t.Error("resolved a pan channel without offsets")
+	}
+	if _, err := resolveFixture(fixture, profile([]int{0, 1}, []int{})); err == nil {
+		t.Error("resolved a channel without offsets")
+	}
+}
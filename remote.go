@@ -0,0 +1,280 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Events emitted to the frontend when a remote control takes over the tracking position.
+const (
+	remotePositionEvent = "remotePosition" // TargetPosition the fixtures of a target are following
+	remoteLockEvent     = "remoteLock"     // bool, whether the mouse position is locked
+)
+
+const defaultOSCPort = 8000
+
+// defaultRemoteConfig returns the remote control settings used until the user changes them.
+func defaultRemoteConfig() RemoteConfig {
+	return RemoteConfig{OscPort: defaultOSCPort, HttpPort: defaultHTTPPort}
+}
+
+// remoteServer is a remote control server. Servers are closed without holding a.mu, as closing waits
+// for their handlers, which lock it.
+type remoteServer interface {
+	Close()
+}
+
+func closeRemoteServers(servers []remoteServer) {
+	for _, server := range servers {
+		server.Close()
+	}
+}
+
+// startRemoteControl starts the remote control servers enabled in the preferences.
+func (a *App) startRemoteControl() {
+	config := defaultRemoteConfig()
+	if prefs := loadPreferences(); prefs.Remote != nil {
+		config = *prefs.Remote
+	}
+	// Preferences saved before a server existed have no port for it
+	if config.OscPort == 0 {
+		config.OscPort = defaultOSCPort
+	}
+	if config.HttpPort == 0 {
+		config.HttpPort = defaultHTTPPort
+	}
+
+	a.mu.Lock()
+	a.remoteConfig = config
+	closing, err := a.applyRemoteConfig()
+	a.mu.Unlock()
+
+	closeRemoteServers(closing)
+	if err != nil {
+		LogError("Failed to start remote control: %s", err.Error())
+	}
+}
+
+// remoteListenIP returns the IP address the servers listen on, the sACN IP address unless another is set.
+func (a *App) remoteListenIP() string {
+	if a.remoteConfig.ListenAddress != "" {
+		return a.remoteConfig.ListenAddress
+	}
+	return a.sacnConfig.IpAddress
+}
+
+// applyRemoteConfig starts, restarts or stops the servers to match the remote control settings and the
+// IP address they listen on. Returns the servers taken out of use, for the caller to close after
+// unlocking a.mu.
+func (a *App) applyRemoteConfig() (closing []remoteServer, err error) {
+	ip := a.remoteListenIP()
+	if ip != "" && net.ParseIP(ip).To4() == nil {
+		closing = append(closing, a.stopRemoteServers()...)
+		return closing, fmt.Errorf("listen address %s is not an IPv4 address", ip)
+	}
+
+	if a.osc != nil && (!a.remoteConfig.OscEnabled || a.osc.port != a.remoteConfig.OscPort || a.osc.ip != ip) {
+		closing = append(closing, a.osc)
+		a.osc = nil
+	}
+
+	if a.remoteConfig.OscEnabled && a.osc == nil && ip != "" {
+		if a.remoteConfig.OscPort < 1 || a.remoteConfig.OscPort > 65535 {
+			return closing, fmt.Errorf("OSC port %d should be between 1 and 65535", a.remoteConfig.OscPort)
+		}
+		osc, err := newOSCServer(ip, a.remoteConfig.OscPort, a.handleOSCMessage)
+		if err != nil {
+			return closing, fmt.Errorf("failed to listen for OSC on %s port %d: %w", ip, a.remoteConfig.OscPort, err)
+		}
+		a.osc = osc
+	}
+
+	if a.http != nil && (!a.remoteConfig.HttpEnabled || a.http.port != a.remoteConfig.HttpPort || a.http.ip != ip) {
+		closing = append(closing, a.http)
+		a.http = nil
+	}
+
+	if a.remoteConfig.HttpEnabled && a.http == nil && ip != "" {
+		if a.remoteConfig.HttpPort < 1 || a.remoteConfig.HttpPort > 65535 {
+			return closing, fmt.Errorf("HTTP port %d should be between 1 and 65535", a.remoteConfig.HttpPort)
+		}
+		http, err := newHTTPServer(ip, a.remoteConfig.HttpPort, a)
+		if err != nil {
+			return closing, fmt.Errorf("failed to listen for HTTP on %s port %d: %w", ip, a.remoteConfig.HttpPort, err)
+		}
+		a.http = http
+	}
+
+	if ip == "" && (a.remoteConfig.OscEnabled || a.remoteConfig.HttpEnabled) {
+		LogInfo("Remote control waits for an sACN IP address to listen on")
+	}
+	return closing, nil
+}
+
+func (a *App) closeRemoteControl() {
+	a.mu.Lock()
+	closing := a.stopRemoteServers()
+	a.mu.Unlock()
+
+	closeRemoteServers(closing)
+}
+
+// stopRemoteServers takes the servers out of use and returns them, for the caller to close after
+// unlocking a.mu.
+func (a *App) stopRemoteServers() []remoteServer {
+	var closing []remoteServer
+	if a.osc != nil {
+		closing = append(closing, a.osc)
+		a.osc = nil
+	}
+	if a.http != nil {
+		closing = append(closing, a.http)
+		a.http = nil
+	}
+	return closing
+}
+
+func (a *App) SetRemoteConfig(config RemoteConfig) {
+	LogInfo("SetRemoteConfig: OscEnabled=%v, OscPort=%d, HttpEnabled=%v, HttpPort=%d, ListenAddress=%s, AllowedOrigins=%v", config.OscEnabled, config.OscPort, config.HttpEnabled, config.HttpPort, config.ListenAddress, config.AllowedOrigins)
+	config.AllowedOrigins = normalizeOrigins(config.AllowedOrigins)
+
+	prefs := loadPreferences()
+	prefs.Remote = &config
+	if err := savePreferences(prefs); err != nil {
+		LogError("Failed to save preferences: %s", err.Error())
+	}
+
+	a.mu.Lock()
+	a.remoteConfig = config
+	closing, err := a.applyRemoteConfig()
+	a.mu.Unlock()
+
+	closeRemoteServers(closing)
+	if err != nil {
+		LogError("Failed to apply remote control settings: %s", err.Error())
+		a.AlertDialog("Remote control", err.Error())
+	}
+}
+
+func (a *App) GetRemoteConfig() RemoteConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.remoteConfig
+}
+
+// normalizeOrigins drops empty origins and trailing slashes, as browsers send origins without them.
+func normalizeOrigins(origins []string) []string {
+	normalized := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSuffix(strings.TrimSpace(origin), "/"); origin != "" {
+			normalized = append(normalized, origin)
+		}
+	}
+	return normalized
+}
+
+// remoteAllowedOrigins returns the origins of web pages allowed to use the HTTP server besides its own.
+func (a *App) remoteAllowedOrigins() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.remoteConfig.AllowedOrigins
+}
+
+// findFixture returns the fixture with the given id, or else the first fixture with the given name,
+// so remote controls can use the names shown in the fixture settings.
+func (a *App) findFixture(idOrName string) (Fixture, bool) {
+	if fixture, exists := a.fixtures[idOrName]; exists {
+		return fixture, true
+	}
+	for _, fixture := range a.fixtures {
+		if fixture.Name == idOrName {
+			return fixture, true
+		}
+	}
+	return Fixture{}, false
+}
+
+// remoteSetPosition makes the fixtures of a target follow a position on the video, 0 to 1 on both axes.
+// The target must be followed by a fixture, so remote controls can not fill the positions with unused names.
+func (a *App) remoteSetPosition(target string, x, y float64) error {
+	if target == "" {
+		target = DefaultTarget
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.IsNaN(y) || math.IsInf(y, 0) {
+		return fmt.Errorf("position %v, %v is not a number", x, y)
+	}
+	x, y = min(max(x, 0), 1), min(max(y, 0), 1)
+
+	a.mu.Lock()
+	if !slices.Contains(a.targets(), target) {
+		a.mu.Unlock()
+		return fmt.Errorf("no fixture follows target %s", target)
+	}
+	a.setTargetPosition(target, x, y)
+	a.mu.Unlock()
+
+	a.emit(remotePositionEvent, TargetPosition{Target: target, X: x, Y: y})
+	return nil
+}
+
+// remoteLock locks or unlocks the mouse position in the frontend, so the mouse does not fight a remote
+// control over the tracking position.
+func (a *App) remoteLock(locked bool) {
+	LogInfo("Remote control set mouse position lock: %v", locked)
+	a.emit(remoteLockEvent, locked)
+}
+
+// remoteSetPanTilt sets the pan and tilt of a fixture by id or name.
+func (a *App) remoteSetPanTilt(idOrName string, pan, tilt int) error {
+	a.mu.Lock()
+	fixture, exists := a.findFixture(idOrName)
+	a.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("no fixture with id or name %s", idOrName)
+	}
+
+	a.SetPanTiltForFixture(fixture.Id, clampDMX16(pan), clampDMX16(tilt))
+	return nil
+}
+
+// remoteSetChannel sets an extra channel, by id or name, of a fixture by id or name.
+func (a *App) remoteSetChannel(fixtureIdOrName, channelIdOrName string, value int) error {
+	a.mu.Lock()
+	fixture, exists := a.findFixture(fixtureIdOrName)
+	a.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("no fixture with id or name %s", fixtureIdOrName)
+	}
+
+	for _, channel := range fixture.Channels {
+		if channel.Id == channelIdOrName || channel.Name == channelIdOrName {
+			a.SetChannelForFixture(fixture.Id, channel.Id, clampDMX16(value))
+			return nil
+		}
+	}
+	return fmt.Errorf("fixture %s has no channel with id or name %s", fixture.Name, channelIdOrName)
+}
+
+// remoteGoCue fades to a cue by id or name, see GoCue.
+func (a *App) remoteGoCue(idOrName string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.goCue(idOrName, time.Now())
+}
+
+func clampDMX16(value int) int {
+	return min(max(value, 0), 65535)
+}
+
+// emit sends an event to the frontend, if it is running.
+func (a *App) emit(event string, data any) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, event, data)
+	}
+}
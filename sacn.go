@@ -1,10 +1,17 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
 	"os"
 	"runtime/debug"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"gitlab.com/patopest/go-sacn"
 	"gitlab.com/patopest/go-sacn/packet"
 )
@@ -18,7 +25,12 @@ func (a *App) sacnWorkerLoop() {
 		panicked := a.sacnWorker()
 
 		a.mu.Lock()
-		a.closeSACNSender()
+		if panicked {
+			a.closeOutputs() // the restarted worker takes over with the same CID
+		} else {
+			a.stopOutputs()
+		}
+		a.closeSACNReceiver()
 		a.mu.Unlock()
 
 		if !panicked {
@@ -35,7 +47,7 @@ func (a *App) sacnWorkerLoop() {
 	}
 }
 
-// sacnWorker runs the sACN send loop. Returns true if it exited due to a panic.
+// sacnWorker runs the send loop. Returns true if it exited due to a panic.
 func (a *App) sacnWorker() (panicked bool) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -44,48 +56,139 @@ func (a *App) sacnWorker() (panicked bool) {
 		}
 	}()
 
-	ticker := time.NewTicker(time.Second)
-	if a.sacnConfig.Fps != 0 {
-		ticker = time.NewTicker(time.Second / time.Duration(a.sacnConfig.Fps))
+	interval := a.sendInterval()
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	nextWork := time.Now().Add(interval)
+	schedule := func(wait time.Duration) {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		nextWork = time.Now().Add(wait)
 	}
 
-	work := func() {
+	// work sends the universes which changed or are due a keep-alive and returns how long to wait
+	// until the next work
+	lastWork := time.Now()
+	work := func(now time.Time) time.Duration {
 		a.mu.Lock()
 		defer a.mu.Unlock()
 
-		a.ensureSACNSender()
-		a.ensureSACNUniverses()
+		fading := a.stepCue(now)
+		// A pause while nothing changed counts as one interval, so the filters do not jump when starting to move
+		moving := a.stepMotionFilters(min(now.Sub(lastWork), interval))
+		lastWork = now
 
-		for uni := range a.activeUniverses {
-			p := packet.NewDataPacket()
-			data := a.universeDMXData[uni]
-			p.SetData(data[:])
-			select {
-			case a.activeUniverses[uni] <- p:
-			default:
-				LogDebug("Channel full for universe %d", uni)
+		a.ensureUniverses()
+		a.ensureSACNReceiver()
+
+		next := keepAliveInterval
+		if moving || fading {
+			next = interval
+		}
+		for uni, protocol := range a.activeUniverses {
+			output := a.outputs[protocol]
+			priority := a.sacnConfig.universeConfig(uni).priority()
+
+			data := a.mergeUniverse(uni, a.universeDMXData[uni], now)
+			if wait := a.transmitWait(uni, data, now, interval); wait > 0 {
+				next = min(next, wait)
+			} else if err := output.Send(uni, startCodeNull, data, priority); err != nil {
+				a.recordSend(uni, protocol, err)
+				next = interval
+			} else {
+				a.recordSend(uni, protocol, nil)
+				a.setTransmitted(uni, data, now)
+				next = min(next, a.transmitWait(uni, data, now, interval))
+			}
+
+			if priorities, send := a.perAddressPrioritiesToSend(uni, now); send {
+				err := output.Send(uni, startCodePerAddressPriority, priorities, priority)
+				if err != nil {
+					delete(a.sentPriorities, uni) // try again next time
+					next = interval
+				}
+				a.recordSend(uni, protocol, err)
+			}
+			if wait, sending := a.perAddressPriorityWait(uni, now); sending {
+				next = min(next, wait)
 			}
 		}
+		return next
 	}
 
 	for {
 		select {
 		case <-a.sacnUpdatedConfig:
-			ticker = time.NewTicker(time.Second)
-			if a.sacnConfig.Fps != 0 {
-				ticker = time.NewTicker(time.Second / time.Duration(a.sacnConfig.Fps))
-			}
+			interval = a.sendInterval()
+			schedule(0)
 		case <-a.sacnStopLoop:
 			return false
-		case <-ticker.C:
-			work()
+		case <-a.sacnDataChanged:
+			// Send straight away, unless that would exceed the frame rate
+			wait := max(interval-time.Since(lastWork), 0)
+			if time.Now().Add(wait).Before(nextWork) {
+				schedule(wait)
+			}
+		case now := <-timer.C:
+			schedule(work(now))
 		}
 	}
 }
 
-func (a *App) ensureSACNSender() error {
-	if a.sender != nil {
-		return nil
+// sendInterval returns the shortest time between two sends of a universe.
+func (a *App) sendInterval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sacnConfig.sendInterval()
+}
+
+func (config *SACNConfig) sendInterval() time.Duration {
+	if config.Fps <= 0 {
+		return time.Second
+	}
+	return time.Second / time.Duration(config.Fps)
+}
+
+// sacnOutput sends universes with sACN (E1.31). Packets are sent on the calling goroutine, the sender
+// of go-sacn sends from a goroutine per universe which reads the routing and removes stopped universes
+// without any locking, racing the worker.
+type sacnOutput struct {
+	conn       *net.UDPConn
+	cid        [16]byte
+	sourceName string
+	stop       chan bool
+
+	mu        sync.Mutex // protects universes, which the discovery loop lists
+	universes map[uint16]*sacnUniverse
+	continued map[uint16]uint8 // last sequence numbers of the output this one replaces, by universe
+}
+
+// sacnUniverse is an sACN universe being sent.
+type sacnUniverse struct {
+	sequence     uint8
+	multicast    bool
+	destinations []*net.UDPAddr
+}
+
+// newSACNOutput creates an sACN output sending from cid, a new CID is generated if it is zero. Universes
+// in continued carry on from the given sequence numbers, so a replaced output's streams continue.
+func newSACNOutput(ip string, cid [16]byte, continued map[uint16]uint8) (*sacnOutput, error) {
+	localIP := net.ParseIP(ip).To4()
+	if localIP == nil {
+		return nil, fmt.Errorf("%s is not an IPv4 address", ip)
+	}
+
+	if cid == ([16]byte{}) {
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		cid = id
 	}
 
 	sourceName := "Folje"
@@ -93,118 +196,233 @@ func (a *App) ensureSACNSender() error {
 	if err == nil {
 		sourceName += "-" + hostname
 	}
-
-	opts := sacn.SenderOptions{
-		SourceName: sourceName,
+	if len(sourceName) > maxSACNSourceName {
+		sourceName = sourceName[:maxSACNSourceName]
 	}
-	sender, err := sacn.NewSender(a.sacnConfig.IpAddress, &opts)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: localIP})
 	if err != nil {
-		LogError("Failed to create sACN sender on IP %s: %s", a.sacnConfig.IpAddress, err.Error())
-		a.sender = nil
-		return err
+		return nil, err
 	}
 
-	LogInfo("Created sACN sender on IP %s (source: %s)", a.sacnConfig.IpAddress, sourceName)
-	a.sender = sender
+	o := &sacnOutput{
+		conn:       conn,
+		cid:        cid,
+		sourceName: sourceName,
+		stop:       make(chan bool),
+		universes:  make(map[uint16]*sacnUniverse),
+		continued:  maps.Clone(continued),
+	}
+	go o.discoveryLoop()
 
-	return nil
+	LogInfo("Created sACN output on IP %s (source: %s)", ip, sourceName)
+	return o, nil
 }
 
-func (a *App) closeSACNSender() {
-	if a.sender == nil {
-		return
-	}
-
-	a.sender.Close()
-	a.sender = nil
+// maxSACNSourceName is the longest source name in bytes, leaving room for the terminating null.
+const maxSACNSourceName = 63
 
-	a.activeUniverses = make(map[uint16]chan<- packet.SACNPacket)
+// sacnMulticastAddress returns the multicast address of a universe, see E1.31 section 9.3.1.
+func sacnMulticastAddress(uni uint16) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(239, 255, byte(uni>>8), byte(uni)), Port: sacn.SACN_PORT}
 }
 
-func (a *App) ensureSACNUniverses() error {
-	err := a.ensureSACNSender()
-	if err != nil {
-		return err
+func (o *sacnOutput) StartUniverse(uni uint16) error {
+	if uni < 1 || uni >= 64000 {
+		return fmt.Errorf("universe %d can not be sent with sACN, should be between 1 and 63999", uni)
 	}
 
-	for uni := range a.activeUniverses {
-		inUse := false
-		for _, fixture := range a.fixtures {
-			if uni == fixture.Universe {
-				inUse = true
-				break
-			}
-		}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.universes[uni] = &sacnUniverse{sequence: o.continued[uni]}
+	delete(o.continued, uni)
+	return nil
+}
 
-		if inUse {
-			continue
-		}
+func (o *sacnOutput) StopUniverse(uni uint16) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.universes, uni)
+}
+
+func (o *sacnOutput) SetRouting(uni uint16, multicast bool, destinations []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 
-		a.deactiveUniverse(uni)
+	universe, exists := o.universes[uni]
+	if !exists {
+		return
 	}
 
-	for _, fixture := range a.fixtures {
-		a.activateUniverse(fixture.Universe)
+	universe.multicast = multicast
+	universe.destinations = make([]*net.UDPAddr, 0, len(destinations))
+	for _, dest := range destinations {
+		addr, err := resolveDestination(dest, sacn.SACN_PORT)
+		if err != nil {
+			LogError("Failed to add destination %s to universe %d: %s", dest, uni, err.Error())
+			continue
+		}
+		universe.destinations = append(universe.destinations, addr)
 	}
+}
 
-	return nil
+func (o *sacnOutput) Send(uni uint16, startCode byte, data DMXData, priority uint8) error {
+	p := packet.NewDataPacket()
+	p.SetStartCode(startCode)
+	p.Priority = priority
+	p.SetData(data[:])
+	return o.send(uni, p)
 }
 
-func (a *App) activateUniverse(uni uint16) {
-	if a.activeUniverses[uni] != nil {
-		return
+// Terminate sends the stream terminated packets of E1.31 section 6.7.1.
+func (o *sacnOutput) Terminate(uni uint16) {
+	for range 3 {
+		p := packet.NewDataPacket()
+		p.SetStreamTerminated(true)
+		if err := o.send(uni, p); err != nil {
+			LogDebug("Failed to terminate universe %d: %s", uni, err.Error())
+			return
+		}
 	}
+}
 
-	if a.sender == nil {
-		LogError("Cannot activate universe %d: sender is nil", uni)
-		return
+// send numbers a data packet with the next sequence number of its universe and sends it to the
+// universe's multicast address and destinations.
+func (o *sacnOutput) send(uni uint16, p *packet.DataPacket) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	universe, exists := o.universes[uni]
+	if !exists {
+		return errors.New("universe is not started")
 	}
 
-	LogInfo("Activating universe %d", uni)
-	universe, err := a.sender.StartUniverse(uni)
+	universe.sequence++
+	p.CID = o.cid
+	p.Universe = uni
+	p.Sequence = universe.sequence
+	p.SetSourceName(o.sourceName)
+	bytes, err := p.MarshalBinary()
 	if err != nil {
-		LogError("Failed to start universe %d: %s", uni, err.Error())
-		return
+		return err
+	}
+
+	var errs []error
+	destinations := universe.destinations
+	if universe.multicast {
+		destinations = append([]*net.UDPAddr{sacnMulticastAddress(uni)}, destinations...)
 	}
-	a.sender.SetMulticast(uni, a.sacnConfig.Multicast)
-	for _, dest := range a.sacnConfig.Destinations {
-		a.sender.AddDestination(uni, dest)
+	for _, dest := range destinations {
+		if _, err := o.conn.WriteToUDP(bytes, dest); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	a.activeUniverses[uni] = universe
+	return errors.Join(errs...)
 }
 
-func (a *App) deactiveUniverse(uni uint16) {
-	if a.activeUniverses[uni] == nil {
-		return
+// sequences returns the last sequence number sent on each universe.
+func (o *sacnOutput) sequences() map[uint16]uint8 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	sequences := make(map[uint16]uint8, len(o.universes))
+	for uni, universe := range o.universes {
+		sequences[uni] = universe.sequence
 	}
+	return sequences
+}
 
-	if a.sender == nil {
-		LogError("Cannot deactivate universe %d: sender is nil", uni)
-		return
+// Close stops sending without terminating the streams.
+func (o *sacnOutput) Close() {
+	close(o.stop)
+	o.conn.Close()
+}
+
+// discoveryLoop announces the universes being sent with E1.31 universe discovery, see section 8.
+func (o *sacnOutput) discoveryLoop() {
+	ticker := time.NewTicker(sacn.UNIVERSE_DISCOVERY_INTERVAL * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, p := range o.discoveryPackets() {
+			bytes, err := p.MarshalBinary()
+			if err == nil {
+				_, err = o.conn.WriteToUDP(bytes, sacnMulticastAddress(sacn.DISCOVERY_UNIVERSE))
+			}
+			if err != nil {
+				LogDebug("Failed to send sACN universe discovery: %s", err.Error())
+			}
+		}
 	}
+}
 
-	LogInfo("Deactivating universe %d", uni)
-	a.sender.StopUniverse(uni)
-	delete(a.activeUniverses, uni)
+// discoveryPackets returns the pages of the universe discovery, up to 512 universes each.
+func (o *sacnOutput) discoveryPackets() []*packet.DiscoveryPacket {
+	o.mu.Lock()
+	universes := make([]uint16, 0, len(o.universes))
+	for uni := range o.universes {
+		universes = append(universes, uni)
+	}
+	o.mu.Unlock()
+	slices.Sort(universes)
+
+	pages := max((len(universes)+511)/512, 1)
+	packets := make([]*packet.DiscoveryPacket, pages)
+	for page := range pages {
+		p := packet.NewDiscoveryPacket()
+		p.Page, p.Last = uint8(page), uint8(pages-1)
+		p.CID = o.cid
+		p.SetSourceName(o.sourceName)
+		p.SetUniverses(universes[page*512 : min((page+1)*512, len(universes))])
+		packets[page] = p
+	}
+	return packets
 }
 
 func (a *App) SetSACNConfig(sacnConfig SACNConfig) {
 	LogInfo("SetSACNConfig: IP=%s, Multicast=%v, FPS=%d, Destinations=%v", sacnConfig.IpAddress, sacnConfig.Multicast, sacnConfig.Fps, sacnConfig.Destinations)
 
+	if err := sacnConfig.validate(); err != nil {
+		LogError("Rejected sACN settings: %s", err.Error())
+		a.AlertDialog("sACN settings", err.Error())
+		return
+	}
+
 	a.mu.Lock()
+	oldConfig := a.sacnConfig
 	a.sacnConfig = &sacnConfig
 
 	// Save the IP address to preferences
 	a.updateLastIpAddress(sacnConfig.IpAddress)
 
-	if a.sender != nil {
-		a.closeSACNSender()
-	}
+	// While the worker sends the home position it closes the outputs and receiver when done, and the
+	// next start uses the new settings
+	if !a.stoppingOutputs {
+		if oldConfig.IpAddress != sacnConfig.IpAddress {
+			// Swap to outputs on the new IP without terminating the streams, the sACN output keeps its CID and
+			// sequence numbers and the worker sends straight away, so receivers do not notice the swap
+			a.closeOutputs()
+			a.closeSACNReceiver()
+		}
 
-	a.ensureSACNSender()
-	a.ensureSACNUniverses()
+		a.ensureUniverses()
+		a.rerouteUniverses(oldConfig)
+		a.ensureSACNReceiver()
+	}
+	// The remote control servers listen on the IP address unless another is set
+	closing, remoteErr := a.applyRemoteConfig()
 	a.mu.Unlock()
 
+	closeRemoteServers(closing)
+	if remoteErr != nil {
+		LogError("Failed to move remote control to IP %s: %s", sacnConfig.IpAddress, remoteErr.Error())
+	}
+
 	select {
 	case a.sacnUpdatedConfig <- true:
 	default:
@@ -214,5 +432,37 @@ func (a *App) SetSACNConfig(sacnConfig SACNConfig) {
 func (a *App) GetSACNConfig() SACNConfig {
 	a.findPossibleIPAddresses()
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	return *a.sacnConfig
 }
+
+// validate returns an error for settings which can not be applied.
+func (config *SACNConfig) validate() error {
+	for _, universeConfig := range config.Universes {
+		if universeConfig.InputUniverse != 0 && universeConfig.InputUniverse == universeConfig.Universe {
+			// Följe would merge its own output back in
+			return fmt.Errorf("universe %d can not use itself as its input universe", universeConfig.Universe)
+		}
+	}
+	return nil
+}
+
+// universeConfig returns the settings of an output universe.
+func (config *SACNConfig) universeConfig(uni uint16) SACNUniverseConfig {
+	for _, universeConfig := range config.Universes {
+		if universeConfig.Universe == uni {
+			return universeConfig
+		}
+	}
+	return SACNUniverseConfig{Universe: uni, MergeMode: MergeModeOff}
+}
+
+// routing returns whether an output universe is multicast and the unicast destinations it is sent to.
+func (config *SACNConfig) routing(uni uint16) (bool, []string) {
+	universeConfig := config.universeConfig(uni)
+	if universeConfig.CustomRouting {
+		return universeConfig.Multicast, universeConfig.Destinations
+	}
+	return config.Multicast, config.Destinations
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/go-sacn/packet"
+)
+
+// listenUDP listens on a free port on the loopback interface, standing in for a node.
+func listenUDP(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// receiveUDP returns the next packet received on conn, nil if none arrives in time.
+func receiveUDP(t *testing.T, conn *net.UDPConn, timeout time.Duration) []byte {
+	t.Helper()
+	buffer := make([]byte, 1500)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	n, _, err := conn.ReadFromUDP(buffer)
+	if err != nil {
+		return nil
+	}
+	return buffer[:n]
+}
+
+func receiveSACNData(t *testing.T, conn *net.UDPConn) *packet.DataPacket {
+	t.Helper()
+	b := receiveUDP(t, conn, time.Second)
+	if b == nil {
+		t.Fatal("no sACN packet received")
+	}
+	p, err := packet.Unmarshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := p.(*packet.DataPacket)
+	if !ok {
+		t.Fatalf("received %T, want a data packet", p)
+	}
+	return data
+}
+
+func newTestSACNOutput(t *testing.T) *sacnOutput {
+	t.Helper()
+	output, err := newSACNOutput("127.0.0.1", [16]byte{0xF0, 0x17}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(output.Close)
+	return output
+}
+
+func TestSACNOutputReroutes(t *testing.T) {
+	output := newTestSACNOutput(t)
+	first, second := listenUDP(t), listenUDP(t)
+
+	if err := output.StartUniverse(7); err != nil {
+		t.Fatal(err)
+	}
+	output.SetRouting(7, false, []string{first.LocalAddr().String()})
+	data := DMXData{}
+	data[0] = 42
+	if err := output.Send(7, startCodeNull, data, 120); err != nil {
+		t.Fatal(err)
+	}
+	p := receiveSACNData(t, first)
+	if p.CID != output.cid || p.Universe != 7 || p.Sequence != 1 || p.Priority != 120 || p.GetData()[0] != 42 {
+		t.Errorf("received CID %x, universe %d, sequence %d, priority %d, data %d", p.CID, p.Universe, p.Sequence, p.Priority, p.GetData()[0])
+	}
+
+	// The stream continues on the new destination only, without restarting its sequence
+	output.SetRouting(7, false, []string{second.LocalAddr().String()})
+	if err := output.Send(7, startCodeNull, data, 120); err != nil {
+		t.Fatal(err)
+	}
+	if p := receiveSACNData(t, second); p.Sequence != 2 {
+		t.Errorf("sequence %d after rerouting, want 2", p.Sequence)
+	}
+	if b := receiveUDP(t, first, 50*time.Millisecond); b != nil {
+		t.Error("the old destination still receives the universe")
+	}
+}
+
+// An output replacing another, as when the IP address changes, continues its streams where the old
+// one stopped, so receivers do not see packets out of sequence.
+func TestSACNOutputContinuesReplacedOutput(t *testing.T) {
+	old, err := newSACNOutput("127.0.0.1", [16]byte{0xF0, 0x17}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := listenUDP(t)
+	if err := old.StartUniverse(7); err != nil {
+		t.Fatal(err)
+	}
+	old.SetRouting(7, false, []string{node.LocalAddr().String()})
+	for range 3 {
+		if err := old.Send(7, startCodeNull, DMXData{}, 100); err != nil {
+			t.Fatal(err)
+		}
+		receiveSACNData(t, node)
+	}
+	old.Close()
+
+	output, err := newSACNOutput("127.0.0.1", old.cid, old.sequences())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	if err := output.StartUniverse(7); err != nil {
+		t.Fatal(err)
+	}
+	output.SetRouting(7, false, []string{node.LocalAddr().String()})
+	if err := output.Send(7, startCodeNull, DMXData{}, 100); err != nil {
+		t.Fatal(err)
+	}
+	if p := receiveSACNData(t, node); p.CID != old.cid || p.Sequence != 4 {
+		t.Errorf("received CID %x, sequence %d, want %x, 4", p.CID, p.Sequence, old.cid)
+	}
+}
+
+func TestSACNOutputRejectsUnknownUniverses(t *testing.T) {
+	output := newTestSACNOutput(t)
+	for _, uni := range []uint16{0, 64000} {
+		if err := output.StartUniverse(uni); err == nil {
+			t.Errorf("started universe %d", uni)
+		}
+	}
+	if err := output.Send(3, startCodeNull, DMXData{}, 100); err == nil {
+		t.Error("sent on a universe which is not started")
+	}
+}
+
+// Stopping and restarting a universe, as switching its protocol back and forth does, must not race the
+// discovery loop or leave the universe stopped.
+func TestSACNOutputRestartsUniverse(t *testing.T) {
+	output := newTestSACNOutput(t)
+	node := listenUDP(t)
+
+	done := make(chan bool)
+	go func() {
+		defer close(done)
+		for range 1000 {
+			output.discoveryPackets()
+		}
+	}()
+	for range 1000 {
+		if err := output.StartUniverse(5); err != nil {
+			t.Fatal(err)
+		}
+		output.StopUniverse(5)
+	}
+	<-done
+
+	if err := output.Send(5, startCodeNull, DMXData{}, 100); err == nil {
+		t.Error("sent on a stopped universe")
+	}
+	if err := output.StartUniverse(5); err != nil {
+		t.Fatal(err)
+	}
+	output.SetRouting(5, false, []string{node.LocalAddr().String()})
+	if err := output.Send(5, startCodeNull, DMXData{}, 100); err != nil {
+		t.Fatal(err)
+	}
+	if p := receiveSACNData(t, node); p.Universe != 5 || p.Sequence != 1 {
+		t.Errorf("received universe %d with sequence %d after restarting, want universe 5 with sequence 1", p.Universe, p.Sequence)
+	}
+}
+
+func TestSACNOutputDiscoveryPackets(t *testing.T) {
+	output := newTestSACNOutput(t)
+	for _, uni := range []uint16{9, 2, 600} {
+		if err := output.StartUniverse(uni); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output.StopUniverse(600)
+
+	packets := output.discoveryPackets()
+	if len(packets) != 1 {
+		t.Fatalf("%d pages, want 1", len(packets))
+	}
+	p := packets[0]
+	if p.CID != output.cid || p.Page != 0 || p.Last != 0 || p.GetNumUniverses() != 2 || p.Universes[0] != 2 || p.Universes[1] != 9 {
+		t.Errorf("page %d of %d from %x lists %v", p.Page, p.Last, p.CID, p.Universes[:p.GetNumUniverses()])
+	}
+}
+
+func TestSACNConfigValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		universes []SACNUniverseConfig
+		valid     bool
+	}{
+		{"no universes", nil, true},
+		{"no input universe", []SACNUniverseConfig{{Universe: 1}}, true},
+		{"other input universe", []SACNUniverseConfig{{Universe: 1, InputUniverse: 2, MergeMode: MergeModeHTP}}, true},
+		{"own input universe", []SACNUniverseConfig{{Universe: 1, InputUniverse: 1, MergeMode: MergeModeHTP}}, false},
+		{"own input universe not merging", []SACNUniverseConfig{{Universe: 3, InputUniverse: 3}}, false},
+	}
+	for _, test := range tests {
+		config := SACNConfig{Universes: test.universes}
+		if err := config.validate(); (err == nil) != test.valid {
+			t.Errorf("%s: validate() = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestSetPossibleIPAddressesKeepsChoice(t *testing.T) {
+	tests := []struct {
+		name, ip  string
+		addresses []string
+		want      string
+	}{
+		{"first address by default", "", []string{"10.0.0.2", "192.168.1.10"}, "10.0.0.2"},
+		{"keeps the picked address", "192.168.1.10", []string{"10.0.0.2", "192.168.1.10"}, "192.168.1.10"},
+		{"keeps an address that is gone", "172.16.0.1", []string{"10.0.0.2"}, "172.16.0.1"},
+		{"no addresses", "", nil, ""},
+	}
+	for _, test := range tests {
+		a := &App{sacnConfig: &SACNConfig{IpAddress: test.ip}}
+		a.setPossibleIPAddresses(test.addresses)
+		if a.sacnConfig.IpAddress != test.want {
+			t.Errorf("%s: IP address %q, want %q", test.name, a.sacnConfig.IpAddress, test.want)
+		}
+	}
+}
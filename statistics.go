@@ -0,0 +1,45 @@
+package main
+
+import "sort"
+
+// recordSend counts a packet sent on a universe, or why it was not. Failures are logged as an error
+// once when a universe starts failing and once when it recovers, the rest only as debug.
+func (a *App) recordSend(uni uint16, protocol string, err error) {
+	stats, exists := a.sendStatistics[uni]
+	if !exists {
+		stats = &SendStatistics{Universe: uni}
+		a.sendStatistics[uni] = stats
+	}
+	stats.Protocol = protocol
+
+	if err == nil {
+		if stats.Failing {
+			LogInfo("Universe %d is sending again", uni)
+			stats.Failing = false
+		}
+		stats.Packets++
+		return
+	}
+
+	stats.Errors++
+	stats.LastError = err.Error()
+	if !stats.Failing {
+		LogError("Failed to send universe %d: %s, further failures are counted in the send statistics", uni, err.Error())
+		stats.Failing = true
+	} else {
+		LogDebug("Failed to send universe %d: %s", uni, err.Error())
+	}
+}
+
+// GetSendStatistics returns the packets sent and failed on every universe since Följe started.
+func (a *App) GetSendStatistics() []SendStatistics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	statistics := make([]SendStatistics, 0, len(a.sendStatistics))
+	for _, stats := range a.sendStatistics {
+		statistics = append(statistics, *stats)
+	}
+	sort.Slice(statistics, func(i, j int) bool { return statistics[i].Universe < statistics[j].Universe })
+	return statistics
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultTarget is the tracking target of fixtures without one. Fixtures following the same target
+// form a group moving together, so several performers can be followed at once by giving their
+// fixtures different targets and driving each target from the mouse or a remote control.
+const DefaultTarget = "main"
+
+// target returns the tracking target the fixture follows.
+func (fixture Fixture) target() string {
+	if target := strings.TrimSpace(fixture.Target); target != "" {
+		return target
+	}
+	return DefaultTarget
+}
+
+// SetTargetPosition moves the fixtures following a target to a position on the video.
+func (a *App) SetTargetPosition(target string, x float64, y float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.setTargetPosition(target, x, y)
+}
+
+// GetTargets returns the tracking targets fixtures follow, the default target first.
+func (a *App) GetTargets() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.targets()
+}
+
+func (a *App) targets() []string {
+	targets := []string{}
+	for _, fixture := range a.fixtures {
+		if target := fixture.target(); target != DefaultTarget && !slices.Contains(targets, target) {
+			targets = append(targets, target)
+		}
+	}
+	slices.Sort(targets)
+	return append([]string{DefaultTarget}, targets...)
+}
+
+// GetTargetPositions returns the positions on the video the fixtures of each target last followed.
+func (a *App) GetTargetPositions() map[string]Point {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	positions := make(map[string]Point, len(a.targetPositions))
+	for _, target := range a.targets() {
+		if position, exists := a.targetPositions[target]; exists {
+			positions[target] = position
+		}
+	}
+	return positions
+}
@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+const (
+	// keepAliveInterval is how often a universe is sent when its data does not change, E1.31 asks for
+	// 800 ms to 1 s so receivers do not time out.
+	keepAliveInterval = 800 * time.Millisecond
+
+	// changeRepeats is how many times the last data is sent at the full rate after it stops changing
+	// before falling back to keep-alive, so a lost packet does not leave a fixture at an old position.
+	changeRepeats = 3
+)
+
+// transmittedUniverse is the data last sent on a universe.
+type transmittedUniverse struct {
+	data    DMXData
+	sent    time.Time
+	repeats int // sends left at the full rate
+}
+
+// transmitWait returns how long to wait before sending data on the universe, 0 to send it now.
+// Changed data is sent at most once per interval and unchanged data as keep-alive.
+func (a *App) transmitWait(uni uint16, data DMXData, now time.Time, interval time.Duration) time.Duration {
+	transmitted, exists := a.transmitted[uni]
+	if !exists {
+		return 0
+	}
+
+	since := now.Sub(transmitted.sent)
+	if data != transmitted.data || transmitted.repeats > 0 {
+		return max(interval-since, 0)
+	}
+	return max(keepAliveInterval-since, 0)
+}
+
+// setTransmitted records that data was sent on the universe.
+func (a *App) setTransmitted(uni uint16, data DMXData, now time.Time) {
+	transmitted, exists := a.transmitted[uni]
+	if !exists || data != transmitted.data {
+		a.transmitted[uni] = &transmittedUniverse{data: data, sent: now, repeats: changeRepeats}
+		return
+	}
+
+	transmitted.sent = now
+	transmitted.repeats = max(transmitted.repeats-1, 0)
+}
+
+// dmxChanged wakes the worker to send changed data without waiting for the next keep-alive.
+func (a *App) dmxChanged() {
+	select {
+	case a.sacnDataChanged <- true:
+	default:
+	}
+}
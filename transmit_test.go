@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransmitWait(t *testing.T) {
+	const interval = 25 * time.Millisecond
+	a := &App{transmitted: make(map[uint16]*transmittedUniverse)}
+	start := time.Unix(100, 0)
+	first, second := DMXData{1}, DMXData{2}
+
+	steps := []struct {
+		name string
+		at   time.Duration
+		data DMXData
+		want time.Duration
+		send bool // record a send after checking the wait
+	}{
+		{"never sent", 0, first, 0, true},
+		{"repeat within the interval", 10 * time.Millisecond, first, 15 * time.Millisecond, false},
+		{"repeat", interval, first, 0, true},
+		{"change within the interval", interval + 5*time.Millisecond, second, 20 * time.Millisecond, false},
+		{"change", 2 * interval, second, 0, true},
+		{"first repeat of the change", 3 * interval, second, 0, true},
+		{"second repeat", 4 * interval, second, 0, true},
+		{"third repeat", 5 * interval, second, 0, true},
+		{"keep-alive after the repeats", 5*interval + 100*time.Millisecond, second, keepAliveInterval - 100*time.Millisecond, false},
+		{"keep-alive", 5*interval + keepAliveInterval, second, 0, true},
+		{"change after keep-alive", 5*interval + keepAliveInterval + 10*time.Millisecond, first, 15 * time.Millisecond, false},
+		{"late change", 5*interval + keepAliveInterval + time.Second, first, 0, true},
+	}
+	for _, step := range steps {
+		now := start.Add(step.at)
+		if got := a.transmitWait(1, step.data, now, interval); got != step.want {
+			t.Errorf("%s: wait %s, want %s", step.name, got, step.want)
+		}
+		if step.send {
+			a.setTransmitted(1, step.data, now)
+		}
+	}
+}
+
+func TestTransmitSchedule(t *testing.T) {
+	const interval = 25 * time.Millisecond
+	a := &App{transmitted: make(map[uint16]*transmittedUniverse)}
+	start := time.Unix(100, 0)
+
+	// Send like the worker does, the data changes once and then stays
+	var sends []time.Duration
+	now := start
+	for now.Sub(start) < 2*time.Second {
+		data := DMXData{1}
+		if now.Sub(start) >= 100*time.Millisecond {
+			data = DMXData{2}
+		}
+		wait := a.transmitWait(1, data, now, interval)
+		if wait == 0 {
+			a.setTransmitted(1, data, now)
+			sends = append(sends, now.Sub(start))
+			wait = a.transmitWait(1, data, now, interval)
+		}
+		// The worker is also woken when the data changes
+		if change := start.Add(100 * time.Millisecond); now.Before(change) && now.Add(wait).After(change) {
+			wait = change.Sub(now)
+		}
+		now = now.Add(wait)
+	}
+
+	want := []time.Duration{
+		0, 25 * time.Millisecond, 50 * time.Millisecond, 75 * time.Millisecond, // first data and its repeats
+		100 * time.Millisecond, 125 * time.Millisecond, 150 * time.Millisecond, 175 * time.Millisecond, // changed data and its repeats
+		175*time.Millisecond + keepAliveInterval, 175*time.Millisecond + 2*keepAliveInterval, // keep-alive
+	}
+	if len(sends) != len(want) {
+		t.Fatalf("sent at %v, want %v", sends, want)
+	}
+	for i := range want {
+		if sends[i] != want[i] {
+			t.Errorf("send %d at %s, want %s", i, sends[i], want[i])
+		}
+	}
+}
@@ -1,6 +1,6 @@
 package main
 
-func (a *App) TypeExporter(calibrationPoint CalibrationPoint, calibratedCalibrationPoint CalibratedCalibrationPoint, fixture Fixture, sacnConfig SACNConfig, dmxData DMXData, point Point, triangle Triangle, panTilt PanTilt) {
+func (a *App) TypeExporter(calibrationPoint CalibrationPoint, calibratedCalibrationPoint CalibratedCalibrationPoint, fixture Fixture, sacnConfig SACNConfig, dmxData DMXData, point Point, triangle Triangle, panTilt PanTilt, fixturePoseReport FixturePoseReport, poseResidual PoseResidual, fixtureControl FixtureControl) {
 	// Explicitly export all types to the frontend, this should be done automatically by wails but when a type is "wrapped" in a map it doesn't seem to work
 }
 
@@ -9,13 +9,21 @@ type PanTilt struct {
 	Tilt int
 }
 
+// FixtureControl is how a fixture follows while running, set live and saved in the show file.
+type FixtureControl struct {
+	Mode string   // FixtureModeFollow if empty, FixtureModePark or FixtureModeRelease
+	Park *PanTilt // position held when parked, the home position if not set
+	Trim PanTilt  // offset added to the pan and tilt the fixture follows
+}
+
 type Triangle struct {
-	Ax float64
-	Ay float64
-	Bx float64
-	By float64
-	Cx float64
-	Cy float64
+	Ax    float64
+	Ay    float64
+	Bx    float64
+	By    float64
+	Cx    float64
+	Cy    float64
+	Layer string
 }
 
 type Point struct {
@@ -24,16 +32,51 @@ type Point struct {
 }
 
 type CalibrationPoint struct {
-	Id   string
-	Name string
-	X    float64
-	Y    float64
+	Id               string
+	Name             string
+	X                float64
+	Y                float64
+	Layer            string // calibration layer, the default layer if empty
+	HasFloorPosition bool
+	FloorX           float64
+	FloorY           float64
+}
+
+// LayerRegion is an area of the video where the given calibration layer is used, e.g. a riser.
+type LayerRegion struct {
+	Layer   string
+	Polygon []Point
+}
+
+// Preset is a stored look fixtures can fade to with a cue: a position on the video all following
+// fixtures point at, or the pan and tilt of each fixture.
+type Preset struct {
+	Id       string
+	Name     string
+	Position *Point             // position on the video, nil to use PanTilt
+	PanTilt  map[string]PanTilt // by fixture id
+}
+
+// Cue fades the following fixtures from where they are to a preset.
+type Cue struct {
+	Id       string
+	Name     string
+	PresetId string
+	FadeTime float64 // seconds
+	Easing   string  // EasingLinear if empty, EasingIn, EasingOut or EasingInOut
+}
+
+// ActiveCue is the cue holding the fixtures, if any.
+type ActiveCue struct {
+	CueId    string  // empty if no cue holds the fixtures
+	Progress float64 // 0 to 1, 1 when the fade is done
 }
 
 type CalibratedCalibrationPoint struct {
-	Id   string
-	Pan  int
-	Tilt int
+	Id       string
+	Pan      int
+	Tilt     int
+	Channels map[string]int // 16 bit value by fixture channel id
 }
 
 type Fixture struct {
@@ -44,15 +87,179 @@ type Fixture struct {
 	FinePanAddress  int
 	TiltAddress     int
 	FineTiltAddress int
+	ProfileId       string // fixture profile deciding the addresses from StartAddress, raw addresses are used if empty
+	ProfileMode     string
+	StartAddress    int
+	PanRangeDegrees float64 // how many degrees the full pan range covers, 0 if unknown
+	PanWrap         string
+	EdgeMode        string
+	Interpolation   string
+	KnownPose       *FixturePose // mounting pose reused from the rig plan or another venue, solved from the calibration if nil
+	MotionFilter    MotionFilter
+	HomePan         int // position sent when stopping with StopModeHome
+	HomeTilt        int
+	Target          string // tracking target the fixture follows, DefaultTarget if empty
+	Channels        []FixtureChannel
 	Calibration     map[string]CalibratedCalibrationPoint
 }
 
+// FixtureChannel is a channel besides pan/tilt, e.g. zoom or focus, interpolated from the values
+// calibrated at the calibration points so it follows the throw distance.
+type FixtureChannel struct {
+	Id             string
+	Name           string
+	ProfileChannel string // name of the profile channel deciding the addresses, the addresses below are used if empty
+	Address        int
+	FineAddress    int
+	Default        int // 16 bit value at calibration points without a value for the channel
+}
+
+// FixtureProfile describes the DMX channels of a fixture type, imported from GDTF or Open Fixture Library.
+type FixtureProfile struct {
+	Id           string
+	Name         string
+	Manufacturer string
+	Source       string // file the profile was imported from
+	Modes        []FixtureProfileMode
+}
+
+type FixtureProfileMode struct {
+	Name      string
+	Footprint int // number of channels
+	Channels  []ProfileChannel
+}
+
+type ProfileChannel struct {
+	Name      string
+	Attribute string // see the ChannelAttribute* constants, empty for channels Följe does not control
+	Offsets   []int  // offset from the start address of every byte, most significant first
+	Default   int    // default value at the full resolution of the channel
+}
+
+// MotionFilter configures the smoothing of a fixture's movement, see the MotionFilter* modes.
+type MotionFilter struct {
+	Mode            string
+	SmoothTime      float64 // seconds, for exponential and spring
+	MinCutoff       float64 // Hz, for one-euro, lower removes more jitter when moving slowly
+	Beta            float64 // for one-euro, higher reduces lag when moving fast
+	MaxVelocity     float64 // full range per second, 0 for no limit
+	MaxAcceleration float64 // full range per second squared, 0 for no limit
+}
+
+type FixturePoseReport struct {
+	Solved           bool
+	Error            string
+	Pose             FixturePose
+	PanRangeDegrees  float64
+	TiltRangeDegrees float64
+	RMSError         float64
+	Residuals        map[string]PoseResidual // by calibration point id
+}
+
 type DMXData [512]byte
 
 type SACNConfig struct {
 	IpAddress           string
 	PossibleIpAddresses []string
 	Fps                 int
-	Multicast           bool
-	Destinations        []string
+	Multicast           bool                 // default for universes without custom routing
+	Destinations        []string             // default for universes without custom routing
+	Universes           []SACNUniverseConfig // settings of single output universes, defaults are used for the others
+	StopMode            string               // what receivers are left with when Följe stops, StopModeHold if empty
+}
+
+// SACNUniverseConfig holds the settings of one output universe.
+type SACNUniverseConfig struct {
+	Universe           uint16
+	Protocol           string // ProtocolSACN or ProtocolArtNet, sACN if empty
+	InputUniverse      uint16 // universe the console sends on which is merged into the output, 0 to not merge
+	MergeMode          string
+	Priority           uint8 // sACN priority 1-200, 0 for the default of 100
+	PerAddressPriority bool  // also send per-address priority (start code 0xDD) so only followed channels win
+	CustomRouting      bool  // send using Multicast and Destinations below instead of the defaults in SACNConfig
+	Multicast          bool
+	Destinations       []string
+}
+
+// ArtNetNode is an Art-Net node found by polling the network.
+type ArtNetNode struct {
+	Ip        string
+	ShortName string
+	LongName  string
+	Universes []uint16 // universes the node outputs, numbered like the universes of fixtures
+}
+
+// MonitoredUniverse is a universe the sACN monitor listens to and the sources received on it.
+type MonitoredUniverse struct {
+	Universe uint16
+	Sources  []MonitoredSource
+}
+
+type MonitoredSource struct {
+	CID                string
+	Name               string
+	Ip                 string
+	Own                bool // sent by Följe
+	Active             bool // false once the source timed out, it is listed for a while after
+	Priority           uint8
+	PerAddressPriority bool // the source sends per-address priority
+	PacketsPerSecond   float64
+	SequenceErrors     int // packets dropped as out of order or duplicate, including ones reordered on arrival
+	LastSeenMs         int64
+	Data               DMXData
+}
+
+// SourceConflict is another source sending an output universe at the same priority as Följe.
+type SourceConflict struct {
+	Universe   uint16
+	CID        string
+	Name       string
+	Ip         string
+	Priority   uint8
+	DurationMs int64 // how long the conflict has lasted
+}
+
+// SendStatistics counts what was sent on a universe.
+type SendStatistics struct {
+	Universe  uint16
+	Protocol  string
+	Packets   int
+	Errors    int // packets the socket failed to write to the network, to any of the universe's destinations
+	LastError string
+	Failing   bool // the last packet failed
+}
+
+// DiscoveredSource is an sACN source found by E1.31 universe discovery.
+type DiscoveredSource struct {
+	CID       string
+	Name      string
+	Ip        string
+	Own       bool     // Följe itself
+	Universes []uint16 // universes the source sends
+}
+
+// RemoteConfig are the settings of the servers remote controls drive Följe through. They are saved in
+// the preferences, not the show file, as the ports depend on the computer.
+type RemoteConfig struct {
+	OscEnabled     bool
+	OscPort        int
+	HttpEnabled    bool
+	HttpPort       int
+	ListenAddress  string   // IP address the servers listen on: empty for the sACN IP address, 127.0.0.1 for this computer only or 0.0.0.0 for every network
+	AllowedOrigins []string // origins of web pages allowed to use the HTTP server besides its own, e.g. http://10.0.0.5:3000
+}
+
+// RemoteState is everything a remote operator needs to follow Följe, served by the HTTP server.
+type RemoteState struct {
+	PanTilt    map[string]PanTilt
+	Targets    map[string]Point // the positions on the video the fixtures of each target last followed
+	Triangles  map[string][]Triangle
+	SACNConfig SACNConfig
+}
+
+// TargetPosition is a position on the video for the fixtures following a tracking target.
+type TargetPosition struct {
+	Target string
+	X      float64
+	Y      float64
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
+// findPossibleIPAddresses looks up the IPv4 addresses of the network interfaces which are up. The
+// lookup and dialogs run without a.mu, the config is updated with it held.
 func (a *App) findPossibleIPAddresses() {
 	interfaces, err := net.Interfaces()
 	if err != nil {
@@ -38,10 +40,21 @@ func (a *App) findPossibleIPAddresses() {
 		}
 	}
 
-	a.sacnConfig.PossibleIpAddresses = possibleAddresses
 	LogInfo("Found %d IP address(es): %v", len(possibleAddresses), possibleAddresses)
 	if len(possibleAddresses) == 0 {
 		a.AlertDialog("No IP addresses found", "This should not happen. Make sure you have a network interface active.")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.setPossibleIPAddresses(possibleAddresses)
+}
+
+// setPossibleIPAddresses lists the IP addresses sACN can be sent from and picks the first one if none
+// is picked yet, keeping the user's choice.
+func (a *App) setPossibleIPAddresses(possibleAddresses []string) {
+	a.sacnConfig.PossibleIpAddresses = possibleAddresses
+	if a.sacnConfig.IpAddress != "" || len(possibleAddresses) == 0 {
 		return
 	}
 	a.sacnConfig.IpAddress = possibleAddresses[0]
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultHTTPPort = 8080
+
+	// readHeaderTimeout is how long a client may take to send the request headers, so idle connections
+	// do not pile up.
+	readHeaderTimeout = 5 * time.Second
+
+	// stateInterval is how often subscribers are sent the pan/tilt and target positions when they changed,
+	// slowStateInterval the triangles and sACN config which rarely change.
+	stateInterval     = 100 * time.Millisecond
+	slowStateInterval = time.Second
+
+	// subscriberBuffer is how many messages a subscriber may fall behind before it is disconnected.
+	subscriberBuffer = 32
+)
+
+// State message types sent to WebSocket subscribers. Subscribers may send position and lock messages.
+const (
+	stateFull       = "state"
+	statePanTilt    = "panTilt"
+	statePosition   = "position"
+	stateTriangles  = "triangles"
+	stateSACNConfig = "sacnConfig"
+	stateTargets    = "targets"
+	stateLock       = "lock"
+)
+
+// sacnConfigChangedEvent is emitted to the frontend when the sACN config is changed remotely.
+const sacnConfigChangedEvent = "sacnConfigChanged"
+
+// stateMessage is a message on the WebSocket, Data depends on Type.
+type stateMessage struct {
+	Type string
+	Data json.RawMessage
+}
+
+// httpServer serves the remote control API and streams state changes to WebSocket subscribers.
+type httpServer struct {
+	app      *App
+	server   *http.Server
+	ip       string
+	port     int
+	upgrader websocket.Upgrader
+	stop     chan bool
+
+	subscribersMu sync.Mutex // protects subscribers and closed, subscribers connect and leave on their own goroutines
+	subscribers   map[chan []byte]bool
+	closed        bool // set by Close, later subscribers are refused
+}
+
+func newHTTPServer(ip string, port int, app *App) (*httpServer, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(ip, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &httpServer{
+		app:         app,
+		ip:          ip,
+		port:        port,
+		stop:        make(chan bool),
+		subscribers: make(map[chan []byte]bool),
+	}
+	s.upgrader = websocket.Upgrader{CheckOrigin: s.allowedOrigin}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/state", s.handleState)
+	mux.HandleFunc("GET /api/pantilt", s.handleGet(func() any { return app.GetFixturePanTilt() }))
+	mux.HandleFunc("GET /api/triangles", s.handleGet(func() any { return app.GetTriangles() }))
+	mux.HandleFunc("GET /api/sacn-config", s.handleGet(func() any { return app.remoteSACNConfig() }))
+	mux.HandleFunc("PUT /api/sacn-config", s.handleSetSACNConfig)
+	mux.HandleFunc("POST /api/position", s.handlePosition)
+	mux.HandleFunc("POST /api/target/{target}/position", s.handlePosition)
+	mux.HandleFunc("POST /api/lock", s.handleLock)
+	mux.HandleFunc("POST /api/fixture/{fixture}/pantilt", s.handlePanTilt)
+	mux.HandleFunc("POST /api/fixture/{fixture}/channel/{channel}", s.handleChannel)
+	mux.HandleFunc("POST /api/cue/{cue}", s.handleCue)
+	mux.HandleFunc("POST /api/cue/release", s.handleRelease)
+	mux.HandleFunc("GET /api/ws", s.handleWebSocket)
+
+	s.server = &http.Server{Handler: s.checkOrigin(mux), ReadHeaderTimeout: readHeaderTimeout}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			LogError("HTTP server on %s port %d stopped: %s", ip, port, err.Error())
+		}
+	}()
+	go s.broadcastLoop()
+
+	LogInfo("Listening for HTTP and WebSocket on %s port %d", ip, port)
+	return s, nil
+}
+
+func (s *httpServer) Close() {
+	LogInfo("Closing HTTP server on %s port %d", s.ip, s.port)
+	close(s.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.server.Shutdown(ctx)
+
+	// Shutdown does not wait for hijacked WebSocket connections, closing the channels ends them
+	s.subscribersMu.Lock()
+	for subscriber := range s.subscribers {
+		close(subscriber)
+	}
+	s.subscribers = make(map[chan []byte]bool)
+	s.closed = true
+	s.subscribersMu.Unlock()
+}
+
+// ownAddress returns true if host, as in the Host header or an origin, is an address the server listens
+// on. A page on another name which resolves to Följe (DNS rebinding) then can not use the server as its
+// own.
+func (s *httpServer) ownAddress(host string) bool {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		name, port = host, "80" // no port given
+	}
+	if port != strconv.Itoa(s.port) {
+		return false
+	}
+	if name == "localhost" {
+		name = "127.0.0.1"
+	}
+	ip, listening := net.ParseIP(name), net.ParseIP(s.ip)
+	if ip == nil || listening == nil {
+		return false
+	}
+	if listening.IsUnspecified() {
+		_, err := interfaceWithIP(ip.String()) // any address of this computer
+		return err == nil
+	}
+	return ip.Equal(listening)
+}
+
+// allowedOrigin returns true for requests not sent by a web page, from a page served by this server or
+// from one of the allowed origins in the remote control settings. Other pages could otherwise drive the
+// fixtures from any browser on the network.
+func (s *httpServer) allowedOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // not a browser, e.g. a show controller or curl
+	}
+	if u, err := url.Parse(origin); err == nil && u.Scheme == "http" && s.ownAddress(u.Host) {
+		return true
+	}
+	return slices.Contains(s.app.remoteAllowedOrigins(), origin)
+}
+
+// checkOrigin refuses requests from pages which are not allowed and lets allowed pages read the responses.
+func (s *httpServer) checkOrigin(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Browsers send no origin for some requests from a page to its own server, so the Host header
+		// is checked for every request
+		if !s.ownAddress(r.Host) {
+			LogError("Refused HTTP request from %s: host %s is not this server", r.RemoteAddr, r.Host)
+			http.Error(w, "host not allowed", http.StatusForbidden)
+			return
+		}
+		if !s.allowedOrigin(r) {
+			LogError("Refused HTTP request from %s: origin %s is not allowed", r.RemoteAddr, r.Header.Get("Origin"))
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		LogDebug("Failed to write HTTP response: %s", err.Error())
+	}
+}
+
+// readJSON decodes the request body into value, answering the request with an error if it fails.
+func readJSON(w http.ResponseWriter, r *http.Request, value any) bool {
+	if err := json.NewDecoder(r.Body).Decode(value); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (s *httpServer) handleGet(get func() any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, get())
+	}
+}
+
+func (s *httpServer) handleState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.app.remoteState())
+}
+
+func (s *httpServer) handleSetSACNConfig(w http.ResponseWriter, r *http.Request) {
+	// Fields left out keep their value, decoding into a deep copy so the slices of the config in use
+	// are not overwritten
+	var config SACNConfig
+	currentConfig := s.app.remoteSACNConfig()
+	current, _ := json.Marshal(currentConfig)
+	json.Unmarshal(current, &config)
+	if !readJSON(w, r, &config) {
+		return
+	}
+	if config.IpAddress != currentConfig.IpAddress {
+		// The server listens on the IP address, so changing it would close the server answering
+		http.Error(w, "IpAddress can only be changed in Följe", http.StatusBadRequest)
+		return
+	}
+	if config.Fps <= 0 {
+		http.Error(w, "Fps should be above 0", http.StatusBadRequest)
+		return
+	}
+
+	LogInfo("sACN config changed over HTTP by %s", r.RemoteAddr)
+	s.app.SetSACNConfig(config)
+	s.app.emit(sacnConfigChangedEvent, nil)
+	writeJSON(w, s.app.remoteSACNConfig())
+}
+
+func (s *httpServer) handlePosition(w http.ResponseWriter, r *http.Request) {
+	var position Point
+	if !readJSON(w, r, &position) {
+		return
+	}
+	if err := s.app.remoteSetPosition(r.PathValue("target"), position.X, position.Y); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) handleLock(w http.ResponseWriter, r *http.Request) {
+	var lock struct{ Locked bool }
+	if !readJSON(w, r, &lock) {
+		return
+	}
+	s.app.remoteLock(lock.Locked)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) handlePanTilt(w http.ResponseWriter, r *http.Request) {
+	var panTilt PanTilt
+	if !readJSON(w, r, &panTilt) {
+		return
+	}
+	if err := s.app.remoteSetPanTilt(r.PathValue("fixture"), panTilt.Pan, panTilt.Tilt); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) handleChannel(w http.ResponseWriter, r *http.Request) {
+	var channel struct{ Value int }
+	if !readJSON(w, r, &channel) {
+		return
+	}
+	if err := s.app.remoteSetChannel(r.PathValue("fixture"), r.PathValue("channel"), channel.Value); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) handleCue(w http.ResponseWriter, r *http.Request) {
+	if err := s.app.remoteGoCue(r.PathValue("cue")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *httpServer) handleRelease(w http.ResponseWriter, r *http.Request) {
+	s.app.ReleaseCue()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleWebSocket sends the full state to a new subscriber followed by every change, and takes
+// position and lock messages from it.
+func (s *httpServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader answered the request
+	}
+	LogInfo("WebSocket subscriber %s connected", r.RemoteAddr)
+
+	send := make(chan []byte, subscriberBuffer)
+	send <- encodeStateMessage(stateFull, s.app.remoteState())
+	if !s.subscribe(send) {
+		// Upgraded while the server was closing, Close would never end the connection
+		conn.Close()
+		LogInfo("Refused WebSocket subscriber %s, the server is closing", r.RemoteAddr)
+		return
+	}
+
+	go func() {
+		defer conn.Close()
+		for message := range send {
+			conn.SetWriteDeadline(time.Now().Add(time.Second))
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		}
+	}()
+
+	defer func() {
+		s.unsubscribe(send)
+		conn.Close()
+		LogInfo("WebSocket subscriber %s disconnected", r.RemoteAddr)
+	}()
+	for {
+		var message stateMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			return
+		}
+		if err := s.app.handleStateMessage(message); err != nil {
+			LogError("Invalid WebSocket message from %s: %s", r.RemoteAddr, err.Error())
+		}
+	}
+}
+
+// subscribe adds a subscriber and returns false if the server is closed.
+func (s *httpServer) subscribe(send chan []byte) bool {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.subscribers[send] = true
+	return true
+}
+
+func (s *httpServer) unsubscribe(send chan []byte) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	if s.subscribers[send] {
+		delete(s.subscribers, send)
+		close(send)
+	}
+}
+
+// broadcast sends a message to all subscribers, dropping those which can not keep up.
+func (s *httpServer) broadcast(message []byte) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for send := range s.subscribers {
+		select {
+		case send <- message:
+		default:
+			LogError("WebSocket subscriber is too slow, disconnecting it")
+			delete(s.subscribers, send)
+			close(send)
+		}
+	}
+}
+
+func (s *httpServer) hasSubscribers() bool {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	return len(s.subscribers) > 0
+}
+
+// broadcastLoop sends the parts of the state that changed to the subscribers.
+func (s *httpServer) broadcastLoop() {
+	ticker := time.NewTicker(stateInterval)
+	defer ticker.Stop()
+
+	last := make(map[string][]byte)
+	sendChanged := func(messageType string, data any) {
+		message := encodeStateMessage(messageType, data)
+		if !bytes.Equal(message, last[messageType]) {
+			last[messageType] = message
+			s.broadcast(message)
+		}
+	}
+
+	lastSlow := time.Time{}
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			if !s.hasSubscribers() {
+				clear(last) // a new subscriber gets the full state
+				continue
+			}
+
+			sendChanged(statePanTilt, s.app.GetFixturePanTilt())
+			sendChanged(stateTargets, s.app.GetTargetPositions())
+			if now.Sub(lastSlow) >= slowStateInterval {
+				lastSlow = now
+				sendChanged(stateTriangles, s.app.GetTriangles())
+				sendChanged(stateSACNConfig, s.app.remoteSACNConfig())
+			}
+		}
+	}
+}
+
+func encodeStateMessage(messageType string, data any) []byte {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		LogError("Failed to encode %s state: %s", messageType, err.Error())
+		encoded = []byte("null")
+	}
+	message, _ := json.Marshal(stateMessage{Type: messageType, Data: encoded})
+	return message
+}
+
+// handleStateMessage applies a message from a WebSocket subscriber.
+func (a *App) handleStateMessage(message stateMessage) error {
+	switch message.Type {
+	case statePosition:
+		var position TargetPosition
+		if err := json.Unmarshal(message.Data, &position); err != nil {
+			return err
+		}
+		return a.remoteSetPosition(position.Target, position.X, position.Y)
+	case stateLock:
+		var locked bool
+		if err := json.Unmarshal(message.Data, &locked); err != nil {
+			return err
+		}
+		a.remoteLock(locked)
+	default:
+		return fmt.Errorf("unknown message type %q", message.Type)
+	}
+	return nil
+}
+
+func (a *App) remoteState() RemoteState {
+	return RemoteState{
+		PanTilt:    a.GetFixturePanTilt(),
+		Targets:    a.GetTargetPositions(),
+		Triangles:  a.GetTriangles(),
+		SACNConfig: a.remoteSACNConfig(),
+	}
+}
+
+// remoteSACNConfig returns the sACN config like GetSACNConfig, without looking for IP addresses.
+func (a *App) remoteSACNConfig() SACNConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return *a.sacnConfig
+}
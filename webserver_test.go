@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHTTPServerChecksOrigin(t *testing.T) {
+	a := &App{remoteConfig: RemoteConfig{AllowedOrigins: []string{"http://10.0.0.5:3000"}}}
+	s := &httpServer{app: a, ip: "192.168.1.10", port: 8080}
+	handled := false
+	handler := s.checkOrigin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		origin      string
+		status      int
+		handled     bool
+		allowOrigin string
+	}{
+		{"no origin", http.MethodPost, "", "", http.StatusNoContent, true, ""},
+		{"own page", http.MethodPost, "", "http://192.168.1.10:8080", http.StatusNoContent, true, "http://192.168.1.10:8080"},
+		{"allowed page", http.MethodPost, "", "http://10.0.0.5:3000", http.StatusNoContent, true, "http://10.0.0.5:3000"},
+		{"allowed preflight", http.MethodOptions, "", "http://10.0.0.5:3000", http.StatusNoContent, false, "http://10.0.0.5:3000"},
+		{"other page", http.MethodPost, "", "http://evil.example", http.StatusForbidden, false, ""},
+		{"other port", http.MethodPost, "", "http://10.0.0.5:3001", http.StatusForbidden, false, ""},
+		{"other preflight", http.MethodOptions, "", "http://evil.example", http.StatusForbidden, false, ""},
+		{"page on another name", http.MethodPost, "evil.example:8080", "http://evil.example:8080", http.StatusForbidden, false, ""},
+		{"another name without origin", http.MethodGet, "evil.example:8080", "", http.StatusForbidden, false, ""},
+		{"own page on another port", http.MethodPost, "", "http://192.168.1.10:3000", http.StatusForbidden, false, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handled = false
+			r := httptest.NewRequest(test.method, "http://192.168.1.10:8080/api/position", nil)
+			if test.host != "" {
+				r.Host = test.host
+			}
+			if test.origin != "" {
+				r.Header.Set("Origin", test.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status || handled != test.handled {
+				t.Errorf("status %d, handled %v, want %d, %v", w.Code, handled, test.status, test.handled)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+				t.Errorf("Access-Control-Allow-Origin %q, want %q", got, test.allowOrigin)
+			}
+		})
+	}
+}
+
+func TestNormalizeOrigins(t *testing.T) {
+	got := normalizeOrigins([]string{" http://10.0.0.5:3000/ ", "", "https://tablet.local"})
+	want := []string{"http://10.0.0.5:3000", "https://tablet.local"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHTTPServerOwnAddress(t *testing.T) {
+	tests := []struct {
+		ip, host string
+		want     bool
+	}{
+		{"192.168.1.10", "192.168.1.10:8080", true},
+		{"192.168.1.10", "192.168.1.10:8081", false},
+		{"192.168.1.10", "192.168.1.10", false},
+		{"192.168.1.10", "192.168.1.11:8080", false},
+		{"192.168.1.10", "folje.example:8080", false},
+		{"192.168.1.10", "localhost:8080", false},
+		{"127.0.0.1", "localhost:8080", true},
+		{"127.0.0.1", "127.0.0.1:8080", true},
+		{"0.0.0.0", "127.0.0.1:8080", true},
+		{"0.0.0.0", "localhost:8080", true},
+		{"0.0.0.0", "192.0.2.1:8080", false}, // TEST-NET-1, not on any interface
+		{"0.0.0.0", "evil.example:8080", false},
+	}
+	for _, test := range tests {
+		s := &httpServer{ip: test.ip, port: 8080}
+		if got := s.ownAddress(test.host); got != test.want {
+			t.Errorf("listening on %s: ownAddress(%s) = %v, want %v", test.ip, test.host, got, test.want)
+		}
+	}
+}
+
+func TestHTTPServerRefusesSubscribersAfterClose(t *testing.T) {
+	s := &httpServer{server: &http.Server{}, ip: "127.0.0.1", stop: make(chan bool), subscribers: make(map[chan []byte]bool)}
+	before := make(chan []byte, subscriberBuffer)
+	if !s.subscribe(before) {
+		t.Fatal("refused a subscriber before closing")
+	}
+
+	s.Close()
+	if _, open := <-before; open {
+		t.Error("closing kept the subscriber")
+	}
+	if s.subscribe(make(chan []byte, subscriberBuffer)) {
+		t.Error("added a subscriber after closing, nothing would end its connection")
+	}
+	if len(s.subscribers) != 0 {
+		t.Errorf("%d subscribers after closing", len(s.subscribers))
+	}
+}
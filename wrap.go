@@ -0,0 +1,89 @@
+package main
+
+import "math"
+
+// Pan wrap modes decide how fixtures with more than 360° of pan pick between pan values aiming at the same spot.
+const (
+	PanWrapNone    = "none"    // use the interpolated pan value as is
+	PanWrapClosest = "closest" // use the pan value aiming at the same spot which is closest to the current pan
+)
+
+// panTurn returns how many pan units one full turn is for the fixture, if it wraps its pan.
+func panTurn(fixture Fixture) (float64, bool) {
+	if fixture.PanWrap != PanWrapClosest || fixture.PanRangeDegrees <= 360 {
+		return 0, false
+	}
+	return maxPanTiltValue * 360 / fixture.PanRangeDegrees, true
+}
+
+// unwrapPanValues adds whole turns to the calibrated pan values so that neighbouring points in the
+// mesh differ by less than half a turn, as if the fixture had unlimited pan. Otherwise two points
+// calibrated on either side of the wrap would be blended into a value aiming somewhere in between.
+func unwrapPanValues(mesh *TriangleMesh, panValues []float64, turn float64) []float64 {
+	neighbours := make([][]int, len(mesh.points))
+	for e := range mesh.tri.Triangles {
+		from, to := mesh.tri.Triangles[e], mesh.tri.Triangles[nextHalfedge(e)]
+		neighbours[from] = append(neighbours[from], to)
+		neighbours[to] = append(neighbours[to], from)
+	}
+
+	unwrapped := make([]float64, len(panValues))
+	visited := make([]bool, len(panValues))
+	for start := range panValues {
+		if visited[start] {
+			continue
+		}
+
+		// Points outside the triangulation, e.g. when all points are on a line, follow the previous point
+		unwrapped[start] = panValues[start]
+		if start > 0 {
+			unwrapped[start] = nearestTurn(panValues[start], unwrapped[start-1], turn)
+		}
+		visited[start] = true
+
+		queue := []int{start}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range neighbours[current] {
+				if visited[next] {
+					continue
+				}
+				unwrapped[next] = nearestTurn(panValues[next], unwrapped[current], turn)
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	// Shift by whole turns so the values are centred in the pan range as far as possible
+	mean := 0.0
+	for _, value := range unwrapped {
+		mean += value / float64(len(unwrapped))
+	}
+	shift := math.Round((mean-maxPanTiltValue/2)/turn) * turn
+	for i := range unwrapped {
+		unwrapped[i] -= shift
+	}
+	return unwrapped
+}
+
+// nearestTurn returns value plus the number of whole turns which brings it closest to reference.
+func nearestTurn(value float64, reference float64, turn float64) float64 {
+	return value + math.Round((reference-value)/turn)*turn
+}
+
+// wrapPan returns the pan value within the pan range aiming the same way as pan which is closest to
+// current, so the fixture never swings the long way round. pan is clamped if no such value exists.
+func wrapPan(pan float64, current float64, turn float64) float64 {
+	best := clampPanTiltValue(pan)
+	bestDistance := math.Inf(1)
+	for k := math.Ceil(-pan / turn); pan+k*turn <= maxPanTiltValue; k++ {
+		candidate := pan + k*turn
+		if distance := math.Abs(candidate - current); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
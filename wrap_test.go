@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fogleman/delaunay"
+)
+
+// Extrapolating past the end of the pan range must reach wrapPan unclamped, so a fixture with more than
+// 360° of pan turns to the same direction one turn back instead of stopping at the end of its range.
+func TestExtrapolatedPanWrapsBeforeClamping(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0, Y: 0}, {X: 0.5, Y: 0}, {X: 0, Y: 1}, {X: 0.5, Y: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewLinear2DPanTiltInterpolator(mesh, []float64{40000, 60000, 40000, 60000}, []float64{20000, 20000, 20000, 20000}, -1, EdgeModeExtend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pan, _, err := interp.Interpolate(delaunay.Point{X: 1, Y: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pan != 80000 {
+		t.Fatalf("extrapolated pan %v, want 80000", pan)
+	}
+
+	turn := maxPanTiltValue * 360 / 540.0
+	tests := []struct {
+		pan, current, want float64
+	}{
+		{80000, 60000, 80000 - turn},
+		{-5000, 10000, -5000 + turn},
+		{50000, 10000, 50000 - turn},
+		{30000, 10000, 30000},
+	}
+	for _, test := range tests {
+		if got := wrapPan(test.pan, test.current, turn); got != test.want {
+			t.Errorf("wrapPan(%v, %v) = %v, want %v", test.pan, test.current, got, test.want)
+		}
+	}
+}
+
+// A trim taking the pan past the end of the range must also wrap rather than be clamped first.
+func TestTrimmedPanWrapsBeforeClamping(t *testing.T) {
+	mesh, err := NewTriangleMesh([]Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interp, err := NewLinear2DPanTiltInterpolator(mesh, []float64{60000, 60000, 60000, 60000}, []float64{20000, 20000, 20000, 20000}, -1, EdgeModeNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture := Fixture{Id: "spot", PanWrap: PanWrapClosest, PanRangeDegrees: 540}
+	turn, _ := panTurn(fixture)
+
+	tests := []struct {
+		name      string
+		fixture   Fixture
+		trim      PanTilt
+		pan, tilt int
+	}{
+		{"wrapping", fixture, PanTilt{Pan: 10000, Tilt: 50000}, int(70000 - turn), maxPanTiltValue},
+		{"not wrapping", Fixture{Id: "spot"}, PanTilt{Pan: 10000, Tilt: -30000}, maxPanTiltValue, 0},
+	}
+	for _, test := range tests {
+		a := &App{
+			linearInterpolators: map[string]map[string]PanTiltInterpolator{defaultLayer: {"spot": interp}},
+			fixtureControls:     map[string]FixtureControl{"spot": {Trim: test.trim}},
+			lastPanTilt:         map[string]PanTilt{"spot": {Pan: 60000, Tilt: 20000}},
+		}
+		got, ok := a.panTiltAt(test.fixture, defaultLayer, Point{X: 0.5, Y: 0.5})
+		if !ok || got.Pan != test.pan || got.Tilt != test.tilt {
+			t.Errorf("%s: panTiltAt = %v, %v, want %d, %d", test.name, got, ok, test.pan, test.tilt)
+		}
+	}
+}
+
+// A pan/tilt fade of a wrapping fixture takes the shortest way round, like following a position does.
+func TestPanTiltFadeWraps(t *testing.T) {
+	turn := maxPanTiltValue * 360 / 540.0
+	tests := []struct {
+		name    string
+		fixture Fixture
+		pan     int
+	}{
+		{"wrapping", Fixture{Id: "spot", PanWrap: PanWrapClosest, PanRangeDegrees: 540, PanAddress: 0, FinePanAddress: -1, TiltAddress: 1, FineTiltAddress: -1}, int(math.Round((60000 + 5000 + turn) / 2))},
+		{"not wrapping", Fixture{Id: "spot", PanAddress: 0, FinePanAddress: -1, TiltAddress: 1, FineTiltAddress: -1}, 32500},
+	}
+	for _, test := range tests {
+		a := &App{
+			fixtures:        map[string]Fixture{"spot": test.fixture},
+			universeDMXData: make(map[uint16]DMXData),
+			lastPanTilt:     make(map[string]PanTilt),
+			cue: &runningCue{cue: Cue{FadeTime: 2, Easing: EasingLinear}, start: time.Unix(0, 0), fades: map[string]*fixtureFade{
+				"spot": {fromPanTilt: PanTilt{Pan: 60000, Tilt: 20000}, toPanTilt: PanTilt{Pan: 5000, Tilt: 30000}},
+			}},
+		}
+		a.stepCue(time.Unix(1, 0))
+		if got := a.lastPanTilt["spot"]; got.Pan != test.pan || got.Tilt != 25000 {
+			t.Errorf("%s: halfway at %v, want pan %d, tilt 25000", test.name, got, test.pan)
+		}
+	}
+}